@@ -5,7 +5,10 @@ package main
 
 import "flag"
 import "fmt"
+import "io"
 import "os"
+import "path/filepath"
+import "strings"
 import "time"
 
 import "github.com/radiand/zettelkasten/internal/application"
@@ -14,19 +17,48 @@ import "github.com/radiand/zettelkasten/internal/application/queries"
 import "github.com/radiand/zettelkasten/internal/common"
 import "github.com/radiand/zettelkasten/internal/config"
 import "github.com/radiand/zettelkasten/internal/git"
+import "github.com/radiand/zettelkasten/internal/workspaces"
 
 // COMMANDS stores help string for all subcommands.
 var COMMANDS = map[string]string{
-	"init":   "Create config and required directories.",
-	"new":    "Create new note.",
-	"link":   "Find link between notes and update headers.",
-	"get":    "Get resource [config [KEY], note [UID], notes [WORKSPACE], workspace, workspaces].",
-	"commit": "Generate commit message and execute git commit.",
+	"init":    "Create config and required directories.",
+	"new":     "Create new note.",
+	"link":    "Find link between notes and update headers.",
+	"get":     "Get resource [config [KEY], neighbours UID, note [UID], notes [WORKSPACE], orphans [WORKSPACE], path UID UID, workspace, workspaces].",
+	"commit":  "Generate commit message and execute git commit.",
+	"search":  "Find notes containing all given words or \"quoted phrases\".",
+	"tags":    "Manage tags [list, rename OLD NEW, merge TAG... INTO, delete TAG].",
+	"check":   "Verify notes and exit with error if problems are found [notes, links, indices].",
+	"graph":   "Export graph of links between notes.",
+	"index":   "Manage index notes, i.e. collections defined in config [build].",
+	"doctor":  "Check the whole zettelkasten for problems and optionally fix the safe ones.",
+	"migrate": "Convert headers of notes in workspace to another format.",
+}
+
+// IndexCommands stores help string for all subcommands of index command.
+var IndexCommands = map[string]string{
+	"build": "Generate index files from tag queries of [[indices]] in config.",
+}
+
+// CheckCommands stores help string for all subcommands of check command.
+var CheckCommands = map[string]string{
+	"notes":   "Report notes which cannot be loaded, e.g. due to malformed header.",
+	"links":   "Report references to notes which do not exist.",
+	"indices": "Report invalid index files and their references to notes which do not exist.",
+}
+
+// TagsCommands stores help string for all subcommands of tags command.
+var TagsCommands = map[string]string{
+	"list":   "List all tags with number of notes labelled with them.",
+	"rename": "Rename tag OLD to NEW in all notes. NEW must not be in use.",
+	"merge":  "Replace all given TAGs with INTO (last argument) in all notes.",
+	"delete": "Remove TAG from all notes.",
 }
 
 type globalArgs struct {
 	configPath string
 	verbose    bool
+	output     queries.OutputFormat
 	subcommand string
 	subArgs    []string
 }
@@ -37,15 +69,60 @@ type cmdCommitArgs struct {
 
 type cmdGetArgs struct {
 	providePath bool
+	tagQuery    string
+	minAge      time.Duration
+	depth       int
+	long        bool
+	format      string
+	sort        string
+	since       string
+	until       string
+	today       bool
+	where       []string
 	query       []string
 }
 
+type cmdSearchArgs struct {
+	caseSensitive bool
+	workspaceName string
+	query         string
+}
+
+type cmdTagsArgs struct {
+	action string
+	tags   []string
+}
+
+type cmdGraphArgs struct {
+	format string
+}
+
 type cmdInitArgs struct {
 	workspaceName string
 }
 
+type cmdLinkArgs struct {
+	dryRun   bool
+	diff     bool
+	isolated bool
+}
+
+type cmdDoctorArgs struct {
+	fix bool
+}
+
+type cmdMigrateArgs struct {
+	headerFormat  string
+	dryRun        bool
+	workspaceName string
+}
+
 type cmdNewArgs struct {
 	workspaceName string
+	templateName  string
+	title         string
+	tags          []string
+	body          string
 }
 
 func parseGlobalArgs() globalArgs {
@@ -59,6 +136,11 @@ func parseGlobalArgs() globalArgs {
 		false,
 		"Turn on verbose messages, e.g. detailed error backtrace",
 	)
+	output := flag.String(
+		"output",
+		string(queries.TextOutput),
+		"Format of query results: text, json or ndjson",
+	)
 	usage := common.BuildUsage("zettelkasten", "Note management").WithCommands(COMMANDS)
 	flag.Usage = func() { common.Flagprint(usage.Render(flag.CommandLine)) }
 	flag.Parse()
@@ -70,11 +152,28 @@ func parseGlobalArgs() globalArgs {
 	}
 	cmd, args := args[0], args[1:]
 
-	return globalArgs{configPath: *configPath, verbose: *verbose, subcommand: cmd, subArgs: args}
+	outputFormat, err := queries.ParseOutputFormat(*output)
+	try(err, "Invalid arguments")
+
+	return globalArgs{
+		configPath: *configPath,
+		verbose:    *verbose,
+		output:     outputFormat,
+		subcommand: cmd,
+		subArgs:    args,
+	}
 }
 
 func parseCmdNew(args []string) cmdNewArgs {
 	flagset := flag.NewFlagSet("new", flag.ExitOnError)
+	templateName := flagset.String(
+		"t",
+		"",
+		"Create note from template, e.g. 'book' for templates/book.md in the workspace or next to config file.",
+	)
+	title := flagset.String("title", "", "Title of the note.")
+	var tags common.StringList
+	flagset.Var(&tags, "tag", "Tag of the note. Can be repeated.")
 	usage := common.BuildUsage(
 		"zettelkasten new", COMMANDS["new"],
 	).WithArguments(
@@ -92,7 +191,23 @@ func parseCmdNew(args []string) cmdNewArgs {
 	if flagset.NArg() == 1 {
 		workspaceName = flagset.Arg(0)
 	}
-	return cmdNewArgs{workspaceName: workspaceName}
+	// Body is taken from stdin only if it is piped, e.g. `echo ... |
+	// zettelkasten new`. Terminals, /dev/null of cron or CI and redirected
+	// files are not read, so they never block or feed unrelated input.
+	body := ""
+	stdinStat, err := os.Stdin.Stat()
+	if err == nil && stdinStat.Mode()&os.ModeNamedPipe != 0 {
+		content, err := io.ReadAll(os.Stdin)
+		try(err, "Cannot read body of the note from stdin")
+		body = string(content)
+	}
+	return cmdNewArgs{
+		workspaceName: workspaceName,
+		templateName:  *templateName,
+		title:         *title,
+		tags:          tags,
+		body:          body,
+	}
 }
 
 func parseCmdCommit(args []string) cmdCommitArgs {
@@ -112,12 +227,172 @@ func parseCmdCommit(args []string) cmdCommitArgs {
 func parseCmdGet(args []string) cmdGetArgs {
 	flagset := flag.NewFlagSet("get", flag.ExitOnError)
 	providePath := flagset.Bool("p", false, "Print path instead of the content.")
+	tagQuery := flagset.String(
+		"t",
+		"",
+		"Filter notes by tags, e.g. 'topic:* AND NOT lang:pl'. Operators: NOT, AND, OR, ( ).",
+	)
+	minAge := flagset.Duration(
+		"min-age",
+		time.Duration(0),
+		"List only notes created at least that long ago, e.g. 720h. Applies to orphans.",
+	)
+	depth := flagset.Int("depth", 1, "How many links away neighbours can be. Applies to neighbours.")
+	long := flagset.Bool(
+		"l",
+		false,
+		"Print notes in tab separated columns: UID, workspace, title, timestamp, tags. Applies to notes.",
+	)
+	format := flagset.String(
+		"format",
+		"",
+		"Go template printing each note, e.g. '{{.Uid}} {{.Title}}'. "+
+			"Fields: Uid, Workspace, Path, Title, Timestamp, Tags, Fields (custom ones, e.g. .Fields.status). "+
+			"Applies to notes.",
+	)
+	sortKey := flagset.String("sort", "", "Sort notes by timestamp, title or uid. Applies to notes.")
+	since := flagset.String(
+		"since",
+		"",
+		"List only notes created since date (2006-01-02), time (RFC3339) or duration ago (e.g. 7d). Applies to notes.",
+	)
+	until := flagset.String(
+		"until",
+		"",
+		"List only notes created until date (inclusive), time or duration ago. Applies to notes.",
+	)
+	today := flagset.Bool("today", false, "List only notes created today. Applies to notes.")
+	var where common.StringList
+	flagset.Var(
+		&where,
+		"where",
+		"List only notes with custom header field of given value, e.g. status=draft. Repeatable. Applies to notes.",
+	)
 	usage := common.BuildUsage("zettelkasten get", COMMANDS["get"])
 	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
 	err := flagset.Parse(args)
 	try(err, "Invalid arguments")
 
-	return cmdGetArgs{providePath: *providePath, query: flagset.Args()}
+	return cmdGetArgs{
+		providePath: *providePath,
+		tagQuery:    *tagQuery,
+		minAge:      *minAge,
+		depth:       *depth,
+		long:        *long,
+		format:      *format,
+		sort:        *sortKey,
+		since:       *since,
+		until:       *until,
+		today:       *today,
+		where:       where,
+		query:       flagset.Args(),
+	}
+}
+
+func parseCmdSearch(args []string) cmdSearchArgs {
+	flagset := flag.NewFlagSet("search", flag.ExitOnError)
+	caseSensitive := flagset.Bool("c", false, "Match letter case exactly.")
+	workspaceName := flagset.String("w", "", "Search only in given workspace.")
+	usage := common.BuildUsage(
+		"zettelkasten search", COMMANDS["search"],
+	).WithArguments(
+		map[string]string{"query": "Words or \"quoted phrases\" to look for in titles, tags and bodies."},
+	)
+	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	if flagset.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Missing query. Provide words or phrases to look for.")
+		os.Exit(1)
+	}
+	// Arguments quoted in shell, e.g. "error handling", are meant to be
+	// phrases.
+	terms := []string{}
+	for _, arg := range flagset.Args() {
+		if strings.ContainsAny(arg, " \t") && !strings.Contains(arg, `"`) {
+			arg = `"` + arg + `"`
+		}
+		terms = append(terms, arg)
+	}
+	return cmdSearchArgs{
+		caseSensitive: *caseSensitive,
+		workspaceName: *workspaceName,
+		query:         strings.Join(terms, " "),
+	}
+}
+
+func parseCmdTags(args []string) cmdTagsArgs {
+	flagset := flag.NewFlagSet("tags", flag.ExitOnError)
+	usage := common.BuildUsage("zettelkasten tags", COMMANDS["tags"]).WithCommands(TagsCommands)
+	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	if flagset.NArg() == 0 {
+		flagset.Usage()
+		os.Exit(1)
+	}
+	action, tags := flagset.Arg(0), flagset.Args()[1:]
+
+	expectedArgs := map[string]func(int) bool{
+		"list":   func(n int) bool { return n == 0 },
+		"rename": func(n int) bool { return n == 2 },
+		"merge":  func(n int) bool { return n >= 2 },
+		"delete": func(n int) bool { return n == 1 },
+	}
+	isValid, ok := expectedArgs[action]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unsupported tags command: '%s'\n", action)
+		os.Exit(1)
+	}
+	if !isValid(len(tags)) {
+		fmt.Fprintf(os.Stderr, "Invalid number of arguments for '%s'. %s\n", action, TagsCommands[action])
+		os.Exit(1)
+	}
+	return cmdTagsArgs{action: action, tags: tags}
+}
+
+func parseCmdCheck(args []string) string {
+	flagset := flag.NewFlagSet("check", flag.ExitOnError)
+	usage := common.BuildUsage("zettelkasten check", COMMANDS["check"]).WithCommands(CheckCommands)
+	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	if flagset.NArg() != 1 {
+		flagset.Usage()
+		os.Exit(1)
+	}
+	if _, ok := CheckCommands[flagset.Arg(0)]; !ok {
+		fmt.Fprintf(os.Stderr, "Unsupported check: '%s'\n", flagset.Arg(0))
+		os.Exit(1)
+	}
+	return flagset.Arg(0)
+}
+
+func parseCmdIndex(args []string) string {
+	flagset := flag.NewFlagSet("index", flag.ExitOnError)
+	usage := common.BuildUsage("zettelkasten index", COMMANDS["index"]).WithCommands(IndexCommands)
+	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	if flagset.NArg() != 1 {
+		flagset.Usage()
+		os.Exit(1)
+	}
+	if _, ok := IndexCommands[flagset.Arg(0)]; !ok {
+		fmt.Fprintf(os.Stderr, "Unsupported index command: '%s'\n", flagset.Arg(0))
+		os.Exit(1)
+	}
+	return flagset.Arg(0)
+}
+
+func parseCmdGraph(args []string) cmdGraphArgs {
+	flagset := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := flagset.String("format", "dot", "Output format: dot, graphml or json.")
+	usage := common.BuildUsage("zettelkasten graph", COMMANDS["graph"])
+	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	return cmdGraphArgs{format: *format}
 }
 
 func parseCmdInit(args []string) cmdInitArgs {
@@ -142,11 +417,45 @@ func parseCmdInit(args []string) cmdInitArgs {
 	return cmdInitArgs{workspaceName: workspaceName}
 }
 
-func parseCmdLink(args []string) {
+func parseCmdLink(args []string) cmdLinkArgs {
 	flagset := flag.NewFlagSet("link", flag.ExitOnError)
+	dryRun := flagset.Bool("dry-run", false, "Report which references would be added or removed, but do not modify notes.")
+	diff := flagset.Bool("diff", false, "Print unified diffs of modified headers.")
+	isolated := flagset.Bool("isolated", false, "Link notes of each workspace separately, ignoring references between workspaces.")
 	usage := common.BuildUsage("zettelkasten link", COMMANDS["link"])
 	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
-	flagset.Parse(args)
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	return cmdLinkArgs{dryRun: *dryRun, diff: *diff, isolated: *isolated}
+}
+
+func parseCmdDoctor(args []string) cmdDoctorArgs {
+	flagset := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fix := flagset.Bool("fix", false, "Fix header UIDs, timestamps and order of tags, where it is safe.")
+	usage := common.BuildUsage("zettelkasten doctor", COMMANDS["doctor"])
+	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	return cmdDoctorArgs{fix: *fix}
+}
+
+func parseCmdMigrate(args []string) cmdMigrateArgs {
+	flagset := flag.NewFlagSet("migrate", flag.ExitOnError)
+	headerFormat := flagset.String("to", "", "Header format to convert to: fenced-toml, toml (+++) or yaml (---).")
+	dryRun := flagset.Bool("dry-run", false, "Report how many notes would be converted, but do not modify them.")
+	usage := common.BuildUsage(
+		"zettelkasten migrate", COMMANDS["migrate"],
+	).WithArguments(
+		map[string]string{"workspace": "(optional) Workspace to migrate. Default from config if not specified."},
+	)
+	flagset.Usage = func() { common.Flagprint(usage.Render(flagset)) }
+	err := flagset.Parse(args)
+	try(err, "Invalid arguments")
+	if *headerFormat == "" || flagset.NArg() > 1 {
+		flagset.Usage()
+		os.Exit(1)
+	}
+	return cmdMigrateArgs{headerFormat: *headerFormat, dryRun: *dryRun, workspaceName: flagset.Arg(0)}
 }
 
 func main() {
@@ -180,15 +489,48 @@ func main() {
 		cmdNewRunner := commands.New{
 			ZettelkastenDir: zettelkastenDir,
 			WorkspaceName:   workspaceName,
-			Nowtime:         common.Now,
+			TemplateName:    parsedArgs.templateName,
+			Title:           parsedArgs.title,
+			Tags:            parsedArgs.tags,
+			Body:            parsedArgs.body,
+			HeaderFormat:    config.GetHeaderFormat(workspaceName),
+			TemplatesDir: filepath.Join(
+				filepath.Dir(common.ExpandHomeDir(globalArgs.configPath)), workspaces.TemplatesDirName,
+			),
+			Nowtime: common.Now,
 		}
 		run(cmdNewRunner, globalArgs.verbose)
 	case "link":
-		parseCmdLink(globalArgs.subArgs)
+		parsedArgs := parseCmdLink(globalArgs.subArgs)
 		cmdLinkRunner := commands.Link{
 			ZettelkastenDir: zettelkastenDir,
+			DryRun:          parsedArgs.dryRun,
+			Diff:            parsedArgs.diff,
+			Isolated:        parsedArgs.isolated,
 		}
 		run(cmdLinkRunner, globalArgs.verbose)
+	case "doctor":
+		parsedArgs := parseCmdDoctor(globalArgs.subArgs)
+		cmdDoctorRunner := commands.Doctor{
+			ZettelkastenDir: zettelkastenDir,
+			Fix:             parsedArgs.fix,
+			Fields:          config.Fields,
+		}
+		run(cmdDoctorRunner, globalArgs.verbose)
+	case "migrate":
+		parsedArgs := parseCmdMigrate(globalArgs.subArgs)
+		workspaceName := config.DefaultWorkspace
+		if parsedArgs.workspaceName != "" {
+			workspaceName = parsedArgs.workspaceName
+		}
+		cmdMigrateRunner := commands.MigrateHeaders{
+			ZettelkastenDir:  zettelkastenDir,
+			WorkspaceName:    workspaceName,
+			HeaderFormat:     parsedArgs.headerFormat,
+			ConfiguredFormat: config.GetHeaderFormat(workspaceName),
+			DryRun:           parsedArgs.dryRun,
+		}
+		run(cmdMigrateRunner, globalArgs.verbose)
 	case "commit":
 		trackedDirectories := []string{zettelkastenDir}
 		parsedArgs := parseCmdCommit(globalArgs.subArgs)
@@ -200,11 +542,84 @@ func main() {
 			Cooldown:   parsedArgs.cooldown,
 		}
 		run(cmdCommitRunner, globalArgs.verbose)
+	case "search":
+		parsedArgs := parseCmdSearch(globalArgs.subArgs)
+		cmdSearchRunner := queries.Search{
+			ZettelkastenDir: zettelkastenDir,
+			Query:           parsedArgs.query,
+			CaseSensitive:   parsedArgs.caseSensitive,
+			WorkspaceName:   parsedArgs.workspaceName,
+			Output:          globalArgs.output,
+		}
+		run(cmdSearchRunner, globalArgs.verbose)
+	case "tags":
+		parsedArgs := parseCmdTags(globalArgs.subArgs)
+		var cmdTagsRunner application.Runnable
+		switch parsedArgs.action {
+		case "list":
+			cmdTagsRunner = queries.Tags{ZettelkastenDir: zettelkastenDir, Output: globalArgs.output}
+		case "rename":
+			cmdTagsRunner = commands.RenameTag{
+				ZettelkastenDir: zettelkastenDir,
+				From:            parsedArgs.tags[0],
+				To:              parsedArgs.tags[1],
+			}
+		case "merge":
+			last := len(parsedArgs.tags) - 1
+			cmdTagsRunner = commands.MergeTags{
+				ZettelkastenDir: zettelkastenDir,
+				From:            parsedArgs.tags[:last],
+				Into:            parsedArgs.tags[last],
+			}
+		case "delete":
+			cmdTagsRunner = commands.DeleteTag{
+				ZettelkastenDir: zettelkastenDir,
+				Tag:             parsedArgs.tags[0],
+			}
+		}
+		run(cmdTagsRunner, globalArgs.verbose)
+	case "check":
+		var cmdCheckRunner application.Runnable
+		switch parseCmdCheck(globalArgs.subArgs) {
+		case "notes":
+			cmdCheckRunner = queries.CheckNotes{ZettelkastenDir: zettelkastenDir, Output: globalArgs.output}
+		case "links":
+			cmdCheckRunner = queries.CheckLinks{ZettelkastenDir: zettelkastenDir, Output: globalArgs.output}
+		case "indices":
+			cmdCheckRunner = queries.CheckIndices{ZettelkastenDir: zettelkastenDir, Output: globalArgs.output}
+		}
+		run(cmdCheckRunner, globalArgs.verbose)
+	case "index":
+		parseCmdIndex(globalArgs.subArgs)
+		cmdIndexRunner := commands.BuildIndices{
+			ZettelkastenDir: zettelkastenDir,
+			Indices:         config.Indices,
+		}
+		run(cmdIndexRunner, globalArgs.verbose)
+	case "graph":
+		parsedArgs := parseCmdGraph(globalArgs.subArgs)
+		cmdGraphRunner := queries.Graph{
+			ZettelkastenDir: zettelkastenDir,
+			Format:          parsedArgs.format,
+		}
+		run(cmdGraphRunner, globalArgs.verbose)
 	case "get":
 		parsedArgs := parseCmdGet(globalArgs.subArgs)
 		cmdGetRunner := queries.Get{
 			ConfigPath:  globalArgs.configPath,
 			ProvidePath: parsedArgs.providePath,
+			TagQuery:    parsedArgs.tagQuery,
+			MinAge:      parsedArgs.minAge,
+			Nowtime:     common.Now,
+			Depth:       parsedArgs.depth,
+			Long:        parsedArgs.long,
+			Format:      parsedArgs.format,
+			Sort:        parsedArgs.sort,
+			Since:       parsedArgs.since,
+			Until:       parsedArgs.until,
+			Today:       parsedArgs.today,
+			Where:       parsedArgs.where,
+			Output:      globalArgs.output,
 			Query:       parsedArgs.query,
 		}
 		run(cmdGetRunner, globalArgs.verbose)
@@ -217,6 +632,10 @@ func main() {
 func run(runnable application.Runnable, verbose bool) {
 	out, err := runnable.Run()
 	if err != nil {
+		// Failed checks still print their structured reports.
+		if out != "" {
+			fmt.Fprintln(os.Stdout, out)
+		}
 		if verbose {
 			fmt.Fprintln(os.Stderr, common.FmtErrors(err))
 		} else {
@@ -0,0 +1,229 @@
+package commands
+
+import "errors"
+import "fmt"
+import "os"
+import "path"
+import "regexp"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// Doctor carries required params to validate the whole zettelkasten.
+type Doctor struct {
+	ZettelkastenDir string
+	// Fix solves problems which are safe to fix, see notes.FixNote.
+	Fix bool
+	// Fields declare custom header fields, which notes are checked against.
+	Fields notes.FieldSchema
+}
+
+// symptom is a problem found by Doctor, located in a file relative to
+// zettelkasten directory.
+type symptom struct {
+	notes.Diagnosis
+	Path string
+	Line int
+}
+
+// Describe formats symptom as `path[:line]: problem`, marked if it can be
+// fixed.
+func (self symptom) Describe(markFixable bool) string {
+	location := self.Path
+	if self.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, self.Line)
+	}
+	line := fmt.Sprintf("  %s: %s", location, self.Problem)
+	if markFixable && self.Fixable {
+		line += " (fixable)"
+	}
+	return line
+}
+
+// Run checks every workspace for files which are not named after note UID,
+// malformed notes, headers inconsistent with file names, unparsable
+// timestamps, unarranged tags, custom fields inconsistent with Fields, UIDs
+// used in more than one workspace and dangling references. Report is grouped
+// by severity. If Fix is set, fixable problems are solved and reported as
+// fixed. If any errors remain, report is returned as an error, so the command
+// can guard e.g. pre-commit hooks.
+func (self Doctor) Run() (string, error) {
+	err := self.Fields.Validate()
+	if err != nil {
+		return "", errors.Join(err, errors.New("Invalid [fields] in config"))
+	}
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+	// UIDs are listed per workspace, as notes of all workspaces cannot be
+	// listed together if some UIDs are duplicated, which is reported below.
+	existing := []string{}
+	for _, ws := range foundWorkspaces {
+		uids, err := notes.NewFilesystemNoteRepository(ws.GetNotesPath()).List()
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot list notes in workspace %s", ws.GetName()))
+		}
+		existing = append(existing, uids...)
+	}
+
+	symptoms := []symptom{}
+	fixed := []symptom{}
+	owners := make(map[string]string)
+	checked := 0
+	for _, ws := range foundWorkspaces {
+		notesDir := path.Join(ws.GetName(), workspaces.NotesDirName)
+		strays, err := findStrayFiles(ws.GetNotesPath())
+		if err != nil {
+			return "", err
+		}
+		for _, name := range strays {
+			symptoms = append(symptoms, symptom{
+				Diagnosis: notes.Diagnosis{
+					Severity: notes.SeverityWarning,
+					Problem:  "File name is not a note UID, so the file is ignored",
+				},
+				Path: path.Join(notesDir, name),
+			})
+		}
+
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		uids, err := repository.List()
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot list notes in workspace %s", ws.GetName()))
+		}
+		for _, uid := range uids {
+			checked++
+			notePath := path.Join(notesDir, uid+".md")
+			if owner, isOwned := owners[uid]; isOwned {
+				symptoms = append(symptoms, symptom{
+					Diagnosis: notes.Diagnosis{
+						Severity: notes.SeverityError,
+						Problem:  fmt.Sprintf("UID is also used in workspace %s", owner),
+					},
+					Path: notePath,
+				})
+			} else {
+				owners[uid] = ws.GetName()
+			}
+
+			nt, err := repository.Get(uid)
+			var parseErr *notes.ParseError
+			if errors.As(err, &parseErr) {
+				symptoms = append(symptoms, symptom{
+					Diagnosis: notes.Diagnosis{
+						Severity: notes.SeverityError,
+						Problem:  strings.ReplaceAll(parseErr.Err.Error(), "\n", ": "),
+					},
+					Path: notePath,
+					Line: parseErr.Line,
+				})
+				continue
+			}
+			if err != nil {
+				return "", errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+			}
+
+			diagnoses := append(notes.DiagnoseNote(uid, nt), self.Fields.Diagnose(nt.Header)...)
+			isFixed := false
+			for _, diagnosis := range diagnoses {
+				found := symptom{Diagnosis: diagnosis, Path: notePath}
+				if self.Fix && diagnosis.Fixable {
+					fixed = append(fixed, found)
+					isFixed = true
+				} else {
+					symptoms = append(symptoms, found)
+				}
+			}
+			if isFixed {
+				_, err = repository.Put(notes.FixNote(uid, nt))
+				if err != nil {
+					return "", errors.Join(err, fmt.Errorf("Cannot save note with UID '%s'", uid))
+				}
+			}
+		}
+
+		dangling, err := notes.FindDanglingReferences(repository, existing)
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot check links in workspace %s", ws.GetName()))
+		}
+		for _, ref := range dangling {
+			symptoms = append(symptoms, symptom{
+				Diagnosis: notes.Diagnosis{
+					Severity: notes.SeverityWarning,
+					Problem:  fmt.Sprintf("Reference to missing note %s", ref.Target),
+				},
+				Path: path.Join(notesDir, ref.Source+".md"),
+				Line: ref.Line,
+			})
+		}
+	}
+
+	return reportSymptoms(symptoms, fixed, checked)
+}
+
+// findStrayFiles returns names of files in notes directory, which are not
+// named `UID.md` and so are not notes. Hidden files are skipped.
+func findStrayFiles(notesPath string) ([]string, error) {
+	entries, err := os.ReadDir(notesPath)
+	if err != nil {
+		return []string{}, errors.Join(err, fmt.Errorf("Cannot list files in %s", notesPath))
+	}
+	noteFileRe := regexp.MustCompile("^" + notes.GetUidRegexp().String() + `\.md$`)
+	strays := []string{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || noteFileRe.MatchString(entry.Name()) {
+			continue
+		}
+		strays = append(strays, entry.Name())
+	}
+	return strays, nil
+}
+
+func reportSymptoms(symptoms []symptom, fixed []symptom, checked int) (string, error) {
+	lines := []string{}
+	section := func(title string, selected []symptom, markFixable bool) {
+		if len(selected) == 0 {
+			return
+		}
+		lines = append(lines, title+":")
+		for _, item := range selected {
+			lines = append(lines, item.Describe(markFixable))
+		}
+	}
+	bySeverity := func(severity notes.Severity) []symptom {
+		selected := []symptom{}
+		for _, item := range symptoms {
+			if item.Severity == severity {
+				selected = append(selected, item)
+			}
+		}
+		return selected
+	}
+	errorSymptoms := bySeverity(notes.SeverityError)
+	warningSymptoms := bySeverity(notes.SeverityWarning)
+	section("Errors", errorSymptoms, true)
+	section("Warnings", warningSymptoms, true)
+	section("Fixed", fixed, false)
+
+	fixable := 0
+	for _, item := range symptoms {
+		if item.Fixable {
+			fixable++
+		}
+	}
+	summary := fmt.Sprintf(
+		"Checked %d notes: %d errors, %d warnings, %d fixed.",
+		checked, len(errorSymptoms), len(warningSymptoms), len(fixed),
+	)
+	if fixable > 0 {
+		summary += fmt.Sprintf(" Run with -fix to solve %d of them.", fixable)
+	}
+	lines = append(lines, summary)
+
+	if len(errorSymptoms) > 0 {
+		return "", errors.New(strings.Join(lines, "\n"))
+	}
+	return strings.Join(lines, "\n"), nil
+}
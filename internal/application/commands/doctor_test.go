@@ -0,0 +1,64 @@
+package commands
+
+import "os"
+import "path"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+func TestDoctor(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	for _, name := range []string{"main", "work"} {
+		err := workspaces.CreateWorkspace(zkdir, name)
+		assert.Nil(t, err)
+	}
+	mainRepo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	workRepo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "work", workspaces.NotesDirName))
+	untidy := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	untidy.Header.Tags = []string{"Book"}
+	untidy.Body = "See [[20300101T000000Z]]."
+	mainRepo.Put(untidy)
+	workRepo.Put(untidy)
+	os.WriteFile(path.Join(zkdir, "main", workspaces.NotesDirName, "todo.txt"), []byte(""), 0644)
+	os.WriteFile(path.Join(zkdir, "work", workspaces.NotesDirName, "20240102T000000Z.md"), []byte("Plain."), 0644)
+
+	// WHEN
+	_, err := Doctor{ZettelkastenDir: zkdir}.Run()
+
+	// THEN
+	expected := "Errors:\n" +
+		"  work/notes/20240101T000000Z.md: UID is also used in workspace main\n" +
+		"  work/notes/20240102T000000Z.md:1: Note has no header\n" +
+		"Warnings:\n" +
+		"  main/notes/todo.txt: File name is not a note UID, so the file is ignored\n" +
+		"  main/notes/20240101T000000Z.md: Tags or references are not lowercase and sorted (fixable)\n" +
+		"  main/notes/20240101T000000Z.md:10: Reference to missing note 20300101T000000Z\n" +
+		"  work/notes/20240101T000000Z.md: Tags or references are not lowercase and sorted (fixable)\n" +
+		"  work/notes/20240101T000000Z.md:10: Reference to missing note 20300101T000000Z\n" +
+		"Checked 3 notes: 2 errors, 5 warnings, 0 fixed. Run with -fix to solve 2 of them."
+	assert.NotNil(t, err)
+	assert.Equal(t, expected, err.Error())
+
+	// WHEN
+	os.Remove(path.Join(zkdir, "work", workspaces.NotesDirName, "20240101T000000Z.md"))
+	os.Remove(path.Join(zkdir, "work", workspaces.NotesDirName, "20240102T000000Z.md"))
+	output, err := Doctor{ZettelkastenDir: zkdir, Fix: true}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	expected = "Warnings:\n" +
+		"  main/notes/todo.txt: File name is not a note UID, so the file is ignored\n" +
+		"  main/notes/20240101T000000Z.md:10: Reference to missing note 20300101T000000Z\n" +
+		"Fixed:\n" +
+		"  main/notes/20240101T000000Z.md: Tags or references are not lowercase and sorted\n" +
+		"Checked 1 notes: 0 errors, 2 warnings, 1 fixed."
+	assert.Equal(t, expected, output)
+	fixed, err := mainRepo.Get("20240101T000000Z")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"book"}, fixed.Header.Tags)
+}
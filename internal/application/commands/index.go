@@ -0,0 +1,148 @@
+package commands
+
+import "errors"
+import "fmt"
+import "os"
+import "path"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/common"
+import "github.com/radiand/zettelkasten/internal/config"
+import "github.com/radiand/zettelkasten/internal/indices"
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// BuildIndices carries required params to generate index files.
+type BuildIndices struct {
+	ZettelkastenDir string
+	Indices         []config.IndexDefinition
+}
+
+// Run writes every index defined in config to index directory of each
+// workspace, listing notes of that workspace. Files are written only if their
+// content changed, and new files are not created for indices without notes.
+// Generated files of indices no longer defined in config are removed, while
+// curated ones are kept. Reports paths of written and removed files, relative
+// to ZettelkastenDir, and summary.
+func (self BuildIndices) Run() (string, error) {
+	if len(self.Indices) == 0 {
+		return "", errors.New("No indices defined. Add [[indices]] tables with name and query to config")
+	}
+	for _, definition := range self.Indices {
+		err := indices.ValidateName(definition.Name)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+
+	lines := []string{}
+	updated := 0
+	unchanged := 0
+	removed := 0
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		invertedIndex, err := notes.UpdateInvertedIndexFile(repository, ws.GetInvertedIndexPath())
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot index notes in workspace %s", ws.GetName()))
+		}
+		for _, definition := range self.Indices {
+			built, err := indices.BuildIndex(&invertedIndex, definition)
+			if err != nil {
+				return "", err
+			}
+			indexPath := path.Join(ws.GetIndexPath(), definition.Name+".md")
+			isPresent, err := common.Exists(indexPath)
+			if err != nil {
+				return "", errors.Join(err, fmt.Errorf("Cannot check index file %s", indexPath))
+			}
+			if len(built.Entries) == 0 && !isPresent {
+				continue
+			}
+			if isPresent && isCurated(indexPath) {
+				return "", fmt.Errorf(
+					"Index file %s is curated by hand and cannot be overwritten. Rename index '%s' in config",
+					indexPath, definition.Name,
+				)
+			}
+			rendered, err := built.Render(workspaces.NotesDirName)
+			if err != nil {
+				return "", err
+			}
+			written, err := indices.WriteIfChanged(indexPath, rendered)
+			if err != nil {
+				return "", err
+			}
+			if !written {
+				unchanged++
+				continue
+			}
+			updated++
+			lines = append(lines, fmt.Sprintf(
+				"%s/%s/%s.md: %d notes", ws.GetName(), workspaces.IndexDirName, definition.Name, len(built.Entries),
+			))
+		}
+
+		stale, err := self.findStaleIndices(ws.GetIndexPath())
+		if err != nil {
+			return "", err
+		}
+		for _, name := range stale {
+			err := os.Remove(path.Join(ws.GetIndexPath(), name+".md"))
+			if err != nil {
+				return "", errors.Join(err, fmt.Errorf("Cannot remove stale index file of '%s'", name))
+			}
+			removed++
+			lines = append(lines, fmt.Sprintf(
+				"%s/%s/%s.md: removed, index is not defined in config", ws.GetName(), workspaces.IndexDirName, name,
+			))
+		}
+	}
+	summary := fmt.Sprintf("Updated %d index files, %d unchanged.", updated, unchanged)
+	if removed > 0 {
+		summary += fmt.Sprintf(" Removed %d stale ones.", removed)
+	}
+	lines = append(lines, summary)
+	return strings.Join(lines, "\n"), nil
+}
+
+// findStaleIndices returns sorted names of generated index files in index
+// directory, which have no definition in Indices anymore.
+func (self BuildIndices) findStaleIndices(indexPath string) ([]string, error) {
+	entries, err := os.ReadDir(indexPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return []string{}, errors.Join(err, fmt.Errorf("Cannot list files in %s", indexPath))
+	}
+	defined := make(map[string]bool)
+	for _, definition := range self.Indices {
+		defined[definition.Name] = true
+	}
+	stale := []string{}
+	for _, entry := range entries {
+		name, isMarkdown := strings.CutSuffix(entry.Name(), ".md")
+		if entry.IsDir() || !isMarkdown || defined[name] {
+			continue
+		}
+		if !isCurated(path.Join(indexPath, entry.Name())) {
+			stale = append(stale, name)
+		}
+	}
+	return stale, nil
+}
+
+// isCurated checks if index file was written by hand rather than generated.
+// Unreadable files are treated as curated, so they are never overwritten.
+func isCurated(indexPath string) bool {
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		return true
+	}
+	return !indices.IsGeneratedContent(string(content))
+}
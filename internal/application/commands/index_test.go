@@ -0,0 +1,87 @@
+package commands
+
+import "os"
+import "path"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/config"
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+func TestBuildIndices(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	for _, name := range []string{"main", "work"} {
+		err := workspaces.CreateWorkspace(zkdir, name)
+		assert.Nil(t, err)
+	}
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	book := notes.NewNote(time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC))
+	book.Header.Title = "Dune"
+	book.Header.Tags = []string{"book"}
+	repo.Put(book)
+
+	cmdBuild := BuildIndices{
+		ZettelkastenDir: zkdir,
+		Indices:         []config.IndexDefinition{{Name: "books", Title: "Books", Query: "book"}},
+	}
+
+	// WHEN
+	firstOutput, firstErr := cmdBuild.Run()
+	secondOutput, secondErr := cmdBuild.Run()
+
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, "main/index/books.md: 1 notes\nUpdated 1 index files, 0 unchanged.", firstOutput)
+	assert.Equal(t, "Updated 0 index files, 1 unchanged.", secondOutput)
+	content, err := os.ReadFile(path.Join(zkdir, "main", workspaces.IndexDirName, "books.md"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "- 20240315T123000Z [Dune](../notes/20240315T123000Z.md)\n")
+	isCreated, _ := os.Stat(path.Join(zkdir, "work", workspaces.IndexDirName, "books.md"))
+	assert.Nil(t, isCreated)
+}
+
+func TestBuildIndicesRemovesStaleFiles(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	book := notes.NewNote(time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC))
+	book.Header.Tags = []string{"book", "travel"}
+	repo.Put(book)
+	indexDir := path.Join(zkdir, "main", workspaces.IndexDirName)
+	_, err = BuildIndices{
+		ZettelkastenDir: zkdir,
+		Indices: []config.IndexDefinition{
+			{Name: "books", Query: "book"},
+			{Name: "travels", Query: "travel"},
+		},
+	}.Run()
+	assert.Nil(t, err)
+	curated := "```toml\nname = \"ideas\"\nquery = \"\"\n```\n\n- 20240315T123000Z\n"
+	os.WriteFile(path.Join(indexDir, "ideas.md"), []byte(curated), 0644)
+
+	// WHEN
+	output, err := BuildIndices{
+		ZettelkastenDir: zkdir,
+		Indices:         []config.IndexDefinition{{Name: "books", Query: "book"}},
+	}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		"main/index/travels.md: removed, index is not defined in config\n"+
+			"Updated 0 index files, 1 unchanged. Removed 1 stale ones.",
+		output,
+	)
+	_, err = os.Stat(path.Join(indexDir, "travels.md"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path.Join(indexDir, "ideas.md"))
+	assert.Nil(t, err)
+}
@@ -1,30 +1,264 @@
 package commands
 
 import "errors"
+import "fmt"
+import "os"
+import "path"
+import "slices"
+import "strings"
 
+import "github.com/radiand/zettelkasten/internal/common"
+import "github.com/radiand/zettelkasten/internal/indices"
 import "github.com/radiand/zettelkasten/internal/notes"
 import "github.com/radiand/zettelkasten/internal/workspaces"
 
 // Link carries required params to run command.
 type Link struct {
 	ZettelkastenDir string
+	// DryRun only reports changes, notes are not modified.
+	DryRun bool
+	// Diff reports changes as unified diffs of headers.
+	Diff bool
+	// Isolated links notes of each workspace separately, so references
+	// between workspaces are ignored.
+	Isolated bool
 }
 
 // Run seeks for references between notes and updates their headers if there
-// are any.
+// are any. Unless Isolated, references are resolved across all workspaces.
+// Reports number of updated and unchanged notes, preceded by malformed notes
+// and index files, which are skipped, and details of changes in dry run or
+// diff mode. Notes keep names of skipped indices in their IndexedIn.
 func (self Link) Run() (string, error) {
-	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	var err error
+	var repositories []*notes.CompositeNoteRepository
+	if self.Isolated {
+		repositories, err = getIsolatedRepositories(self.ZettelkastenDir)
+	} else {
+		var repository *notes.CompositeNoteRepository
+		repository, err = workspaces.GetNoteRepository(self.ZettelkastenDir)
+		repositories = append(repositories, repository)
+	}
 	if err != nil {
 		return "", errors.Join(err, errors.New("Could not link because no workspaces were found"))
 	}
 
+	indexedIn, invalidIndices, err := findIndexedIn(self.ZettelkastenDir)
+	if err != nil {
+		return "", err
+	}
+
+	reports := []string{}
+	for _, invalid := range invalidIndices {
+		reports = append(reports, fmt.Sprintf(
+			"Skipped index file %s: %s", invalid.Path, strings.ReplaceAll(invalid.Err.Error(), "\n", ": "),
+		))
+	}
+	updated, unchanged, skipped := 0, 0, 0
+	for _, repository := range repositories {
+		uids, err := repository.List()
+		if err != nil {
+			return "", errors.Join(err, errors.New("Cannot list notes"))
+		}
+		malformed, err := notes.FindMalformedNotes(repository)
+		if err != nil {
+			return "", errors.Join(err, errors.New("CmdLink failed"))
+		}
+		for _, note := range malformed {
+			workspaceName, _ := repository.Locate(note.Uid)
+			notePath := path.Join(workspaceName, workspaces.NotesDirName, note.Uid+".md")
+			reports = append(reports, "Skipped "+note.Err.WithPath(notePath).Error())
+		}
+		skipped += len(malformed)
+		err = keepIndexedIn(repository, invalidIndices, indexedIn)
+		if err != nil {
+			return "", errors.Join(err, errors.New("CmdLink failed"))
+		}
+		var changes []notes.HeaderChange
+		if self.DryRun {
+			changes, err = notes.PlanLinks(repository, indexedIn)
+		} else {
+			changes, err = notes.LinkNotes(repository, indexedIn)
+		}
+		if err != nil {
+			return "", errors.Join(err, errors.New("CmdLink failed"))
+		}
+		updated += len(changes)
+		unchanged += len(uids) - len(changes) - len(malformed)
+
+		for _, change := range changes {
+			workspaceName, _ := repository.Locate(change.Uid)
+			var report string
+			if self.Diff {
+				report, err = self.diffHeaders(workspaceName, change)
+				if err != nil {
+					return "", err
+				}
+			} else if self.DryRun {
+				report = summarizeHeaderChange(workspaceName, change)
+			}
+			if report != "" {
+				reports = append(reports, report)
+			}
+		}
+	}
+
+	var summary string
+	if self.DryRun {
+		summary = fmt.Sprintf("Would update %d notes, %d unchanged", updated, unchanged)
+	} else {
+		summary = fmt.Sprintf("Updated %d notes, %d unchanged", updated, unchanged)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	reports = append(reports, summary+".")
+	return strings.Join(reports, "\n"), nil
+}
+
+// getIsolatedRepositories creates separate repository for every workspace.
+func getIsolatedRepositories(zettelkastenDir string) ([]*notes.CompositeNoteRepository, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(zettelkastenDir)
+	if err != nil {
+		return nil, err
+	}
+	repositories := []*notes.CompositeNoteRepository{}
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewCompositeNoteRepository()
+		repository.Add(ws.GetName(), notes.NewFilesystemNoteRepository(ws.GetNotesPath()))
+		repositories = append(repositories, repository)
+	}
+	return repositories, nil
+}
+
+// invalidIndex is an index file which cannot be read, so its members are not
+// known.
+type invalidIndex struct {
+	Name string
+	// Path is relative to zettelkasten directory.
+	Path string
+	Err  error
+}
+
+// findIndexedIn reads index files of all workspaces and maps Uids of notes to
+// names of indices listing them. Index with query lists matching notes of its
+// own workspace only. Index files which cannot be read or have invalid query
+// are returned separately, so one of them does not stop linking.
+func findIndexedIn(zettelkastenDir string) (notes.ReferenceMap, []invalidIndex, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(zettelkastenDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	indexedIn := make(notes.ReferenceMap)
+	invalid := []invalidIndex{}
 	for _, ws := range foundWorkspaces {
+		names, err := indices.ListIndexFiles(ws.GetIndexPath())
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(names) == 0 {
+			continue
+		}
 		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
-		err := notes.LinkNotes(repository)
+		invertedIndex, err := notes.UpdateInvertedIndexFile(repository, ws.GetInvertedIndexPath())
 		if err != nil {
-			return "", errors.Join(err, errors.New("CmdLink failed"))
+			return nil, nil, errors.Join(err, fmt.Errorf("Cannot index notes in workspace %s", ws.GetName()))
+		}
+		for _, name := range names {
+			indexPath := path.Join(ws.GetName(), workspaces.IndexDirName, name+".md")
+			indexFile, err := indices.GetIndexFile(ws.GetIndexPath(), name)
+			if err != nil {
+				invalid = append(invalid, invalidIndex{Name: name, Path: indexPath, Err: err})
+				continue
+			}
+			members, err := indexFile.FindMembers(&invertedIndex)
+			if err != nil {
+				invalid = append(invalid, invalidIndex{Name: name, Path: indexPath, Err: err})
+				continue
+			}
+			for _, uid := range members {
+				indexedIn[uid] = append(indexedIn[uid], indexFile.Header.Name)
+			}
 		}
 	}
+	return indexedIn, invalid, nil
+}
 
-	return "", nil
+// keepIndexedIn adds names of invalid indices, which Notes of repository
+// already have in their IndexedIn, to indexedIn, so linking does not remove
+// them. Malformed Notes are left out, see notes.FindMalformedNotes.
+func keepIndexedIn(repository notes.INoteRepository, invalid []invalidIndex, indexedIn notes.ReferenceMap) error {
+	if len(invalid) == 0 {
+		return nil
+	}
+	uids, err := repository.List()
+	if err != nil {
+		return errors.Join(err, errors.New("Cannot list notes"))
+	}
+	for _, uid := range uids {
+		note, err := repository.Get(uid)
+		if err != nil {
+			continue
+		}
+		for _, index := range invalid {
+			if slices.Contains(note.Header.IndexedIn, index.Name) {
+				indexedIn[uid] = append(indexedIn[uid], index.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func summarizeHeaderChange(workspaceName string, change notes.HeaderChange) string {
+	lines := []string{}
+	summarize := func(field string, added []string, removed []string) {
+		if len(added) == 0 && len(removed) == 0 {
+			return
+		}
+		items := []string{}
+		for _, uid := range added {
+			items = append(items, "+"+uid)
+		}
+		for _, uid := range removed {
+			items = append(items, "-"+uid)
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", field, strings.Join(items, " ")))
+	}
+	summarize("refers_to", change.AddedRefersTo(), change.RemovedRefersTo())
+	summarize("referred_from", change.AddedReferredFrom(), change.RemovedReferredFrom())
+	summarize("indexed_in", change.AddedIndexedIn(), change.RemovedIndexedIn())
+	if len(lines) == 0 {
+		return ""
+	}
+	title := path.Join(workspaceName, change.Uid)
+	return strings.Join(append([]string{title}, lines...), "\n")
+}
+
+// diffHeaders formats change as unified diff of the header block of note
+// file, marshalled with its codec. Blank lines preceding the block are kept,
+// so line numbers match the ones in note file.
+func (self Link) diffHeaders(workspaceName string, change notes.HeaderChange) (string, error) {
+	notePath := path.Join(workspaceName, workspaces.NotesDirName, change.Uid+".md")
+	content, err := os.ReadFile(path.Join(self.ZettelkastenDir, notePath))
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Cannot read note with UID '%s'", change.Uid))
+	}
+	raw, err := notes.SplitNote(string(content))
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Cannot read header of note with UID '%s'", change.Uid))
+	}
+	before, err := raw.Codec.Marshal(change.Before)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Cannot marshall header of note with UID '%s'", change.Uid))
+	}
+	after, err := raw.Codec.Marshal(change.After)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Cannot marshall header of note with UID '%s'", change.Uid))
+	}
+	// Headers are marshalled with LF, so fences follow them whatever line
+	// endings the file has.
+	opening := strings.ReplaceAll(raw.Opening, "\r\n", "\n")
+	closing := strings.TrimRight(raw.Closing, "\r\n") + "\n"
+	diff := common.UnifiedDiff("a/"+notePath, "b/"+notePath, opening+before+closing, opening+after+closing)
+	return strings.TrimSuffix(diff, "\n"), nil
 }
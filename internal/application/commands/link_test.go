@@ -0,0 +1,161 @@
+package commands
+
+import "os"
+import "path"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+func TestLinkReports(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	note1 := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note1.Header.Title = "First"
+	repo.Put(note1)
+	note2 := notes.NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	note2.Header.Title = "Second"
+	note2.Body = "See [[20240101T000000Z]]."
+	repo.Put(note2)
+
+	testCases := []struct {
+		testName string
+		cmd      Link
+		expected string
+	}{
+		{
+			"Dry run",
+			Link{ZettelkastenDir: zkdir, DryRun: true},
+			"main/20240101T000000Z\n" +
+				"  referred_from: +20240102T000000Z\n" +
+				"main/20240102T000000Z\n" +
+				"  refers_to: +20240101T000000Z\n" +
+				"Would update 2 notes, 0 unchanged.",
+		},
+		{
+			"Diff",
+			Link{ZettelkastenDir: zkdir, DryRun: true, Diff: true},
+			"--- a/main/notes/20240101T000000Z.md\n" +
+				"+++ b/main/notes/20240101T000000Z.md\n" +
+				"@@ -3,6 +3,6 @@\n" +
+				" timestamp = \"2024-01-01T00:00:00+00:00\"\n" +
+				" uid = \"20240101T000000Z\"\n" +
+				" tags = []\n" +
+				"-referred_from = []\n" +
+				"+referred_from = [\"20240102T000000Z\"]\n" +
+				" refers_to = []\n" +
+				" ```\n" +
+				"--- a/main/notes/20240102T000000Z.md\n" +
+				"+++ b/main/notes/20240102T000000Z.md\n" +
+				"@@ -4,5 +4,5 @@\n" +
+				" uid = \"20240102T000000Z\"\n" +
+				" tags = []\n" +
+				" referred_from = []\n" +
+				"-refers_to = []\n" +
+				"+refers_to = [\"20240101T000000Z\"]\n" +
+				" ```\n" +
+				"Would update 2 notes, 0 unchanged.",
+		},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			output, err := tc.cmd.Run()
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, output)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+
+	// Dry run does not modify notes.
+	unchanged, _ := repo.Get(note1.Header.Uid)
+	assert.Equal(t, []string{}, unchanged.Header.ReferredFrom)
+}
+
+func TestLinkSkipsInvalidIndexFiles(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	note1 := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note1.Header.IndexedIn = []string{"ideas"}
+	repo.Put(note1)
+	note2 := notes.NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	repo.Put(note2)
+	indexDir := path.Join(zkdir, "main", workspaces.IndexDirName)
+	os.MkdirAll(indexDir, 0755)
+	os.WriteFile(path.Join(indexDir, "ideas.md"), []byte("# Ideas\n\n- 20240101T000000Z\n"), 0644)
+	os.WriteFile(
+		path.Join(indexDir, "books.md"),
+		[]byte("```toml\nname = \"books\"\nquery = \"\"\n```\n\n- 20240102T000000Z\n"),
+		0644,
+	)
+
+	// WHEN
+	output, err := Link{ZettelkastenDir: zkdir}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		"Skipped index file main/index/ideas.md: Index file has no header\n"+
+			"Updated 1 notes, 1 unchanged.",
+		output,
+	)
+	kept, err := repo.Get(note1.Header.Uid)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ideas"}, kept.Header.IndexedIn)
+	indexed, err := repo.Get(note2.Header.Uid)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"books"}, indexed.Header.IndexedIn)
+}
+
+func TestLinkDiffFollowsNoteFile(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	notesDir := path.Join(zkdir, "main", workspaces.NotesDirName)
+	os.WriteFile(
+		path.Join(notesDir, "20240101T000000Z.md"),
+		[]byte("\n---\n"+
+			"title: First\n"+
+			"timestamp: \"2024-01-01T00:00:00+00:00\"\n"+
+			"uid: 20240101T000000Z\n"+
+			"tags: []\n"+
+			"referred_from: []\n"+
+			"refers_to: []\n"+
+			"---\n\n"+
+			"See [[20240102T000000Z]].\n"),
+		0644,
+	)
+	repo := notes.NewFilesystemNoteRepository(notesDir)
+	repo.Put(notes.NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+	// WHEN
+	output, err := Link{ZettelkastenDir: zkdir, DryRun: true, Diff: true}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	expected := "--- a/main/notes/20240101T000000Z.md\n" +
+		"+++ b/main/notes/20240101T000000Z.md\n" +
+		"@@ -5,5 +5,5 @@\n" +
+		" uid: 20240101T000000Z\n" +
+		" tags: []\n" +
+		" referred_from: []\n" +
+		"-refers_to: []\n" +
+		"+refers_to: [20240102T000000Z]\n" +
+		" ---\n"
+	assert.Contains(t, output, expected)
+}
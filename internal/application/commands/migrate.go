@@ -0,0 +1,96 @@
+package commands
+
+import "errors"
+import "fmt"
+import "path"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// MigrateHeaders carries required params to convert headers of notes of a
+// workspace to another format.
+type MigrateHeaders struct {
+	ZettelkastenDir string
+	WorkspaceName   string
+	// HeaderFormat to convert to, see notes.GetHeaderCodec.
+	HeaderFormat string
+	// ConfiguredFormat is header_format of the workspace in config, which new
+	// notes follow.
+	ConfiguredFormat string
+	// DryRun only reports how many notes would be converted.
+	DryRun bool
+}
+
+// Run rewrites headers of all notes of the workspace in HeaderFormat, keeping
+// their bodies intact. Malformed notes are skipped and reported. New notes
+// follow header_format of the workspace in config, which is not modified, so
+// if it differs from HeaderFormat, a hint to update it is reported.
+func (self MigrateHeaders) Run() (string, error) {
+	if ok, err := workspaces.IsOkay(self.ZettelkastenDir, self.WorkspaceName); !ok {
+		return "", errors.Join(err, fmt.Errorf("Cannot migrate notes of invalid workspace %s", self.WorkspaceName))
+	}
+	codec, err := notes.GetHeaderCodec(self.HeaderFormat)
+	if err != nil {
+		return "", err
+	}
+
+	repo := notes.NewFilesystemNoteRepository(
+		path.Join(self.ZettelkastenDir, self.WorkspaceName, workspaces.NotesDirName),
+	)
+	uids, err := repo.List()
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot list notes"))
+	}
+
+	reports := []string{}
+	converted, unchanged, skipped := 0, 0, 0
+	for _, uid := range uids {
+		content, err := repo.GetRaw(uid)
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+		}
+		result, isConverted, err := notes.ConvertHeader(content, codec)
+		var parseErr *notes.ParseError
+		if errors.As(err, &parseErr) {
+			notePath := path.Join(self.WorkspaceName, workspaces.NotesDirName, uid+".md")
+			reports = append(reports, "Skipped "+parseErr.WithPath(notePath).Error())
+			skipped++
+			continue
+		}
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot convert note with UID '%s'", uid))
+		}
+		if !isConverted {
+			unchanged++
+			continue
+		}
+		converted++
+		if self.DryRun {
+			continue
+		}
+		err = repo.PutRaw(uid, result)
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot save note with UID '%s'", uid))
+		}
+	}
+
+	verb := "Converted"
+	if self.DryRun {
+		verb = "Would convert"
+	}
+	summary := fmt.Sprintf("%s %d notes to %s, %d unchanged", verb, converted, codec.Name(), unchanged)
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	reports = append(reports, summary+".")
+
+	configured, err := notes.GetHeaderCodec(self.ConfiguredFormat)
+	if err != nil || configured.Name() != codec.Name() {
+		reports = append(reports, fmt.Sprintf(
+			"New notes follow config, so set header_format = \"%s\" in [workspaces.%s] section of it.",
+			codec.Name(), self.WorkspaceName,
+		))
+	}
+	return strings.Join(reports, "\n"), nil
+}
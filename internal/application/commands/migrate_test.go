@@ -0,0 +1,50 @@
+package commands
+
+import "os"
+import "path"
+import "strings"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+func TestMigrateHeaders(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	notesDir := path.Join(zkdir, "main", workspaces.NotesDirName)
+	repo := notes.NewFilesystemNoteRepository(notesDir)
+	note := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note.Header.Title = "Dune"
+	note.Body = "Body."
+	repo.Put(note)
+	os.WriteFile(path.Join(notesDir, "20240102T000000Z.md"), []byte("No header."), 0644)
+
+	cmdMigrate := MigrateHeaders{ZettelkastenDir: zkdir, WorkspaceName: "main", HeaderFormat: notes.YamlFormat}
+
+	// WHEN
+	firstOutput, firstErr := cmdMigrate.Run()
+	cmdMigrate.ConfiguredFormat = notes.YamlFormat
+	secondOutput, secondErr := cmdMigrate.Run()
+
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(
+		t,
+		"Skipped main/notes/20240102T000000Z.md:1: Note has no header\n"+
+			"Converted 1 notes to yaml, 0 unchanged, 1 skipped.\n"+
+			"New notes follow config, so set header_format = \"yaml\" in [workspaces.main] section of it.",
+		firstOutput,
+	)
+	assert.True(t, strings.HasSuffix(secondOutput, "Converted 0 notes to yaml, 1 unchanged, 1 skipped."))
+	content, _ := repo.GetRaw(note.Header.Uid)
+	assert.True(t, strings.HasPrefix(content, "---\ntitle: Dune\n"))
+	migrated, err := repo.Get(note.Header.Uid)
+	assert.Nil(t, err)
+	assert.Equal(t, note, migrated)
+}
@@ -1,32 +1,143 @@
 package commands
 
 import "errors"
+import "fmt"
+import "os"
 import "path"
+import "slices"
+import "strings"
 import "time"
 
 import "github.com/radiand/zettelkasten/internal/notes"
 import "github.com/radiand/zettelkasten/internal/workspaces"
 
+// maxCreateAttempts limits how many times New tries to save a note when its
+// Uid turns out to be taken.
+const maxCreateAttempts = 10
+
 // New carries required params to run command.
 type New struct {
 	ZettelkastenDir string
 	WorkspaceName   string
-	Nowtime         func() time.Time
+	// TemplateName selects `<name>.md` template of the note, looked up in
+	// templates directory of the workspace and then in TemplatesDir. Empty
+	// name creates blank note.
+	TemplateName string
+	TemplatesDir string
+	// Title, Tags and Body prefill the note. Title replaces the one from
+	// template, Tags are added to template's ones and arranged, see
+	// notes.Header.Arrange, and Body is appended to template's body.
+	Title string
+	Tags  []string
+	Body  string
+	// HeaderFormat of the note, see notes.GetHeaderCodec. Empty format
+	// selects the default one.
+	HeaderFormat string
+	Nowtime      func() time.Time
 }
 
 // Run creates new note file and prints its path to stdout.
 func (self New) Run() (string, error) {
-	newNote := notes.NewNote(self.Nowtime())
 	if ok, err := workspaces.IsOkay(self.ZettelkastenDir, self.WorkspaceName); !ok {
 		return "", errors.Join(
 			err, errors.New("Cannot create note in invalid workspace. Consider initializing workspace before"),
 		)
 	}
+
+	codec, err := notes.GetHeaderCodec(self.HeaderFormat)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Invalid header_format of workspace %s in config", self.WorkspaceName))
+	}
+
+	// Uids must be unique across all workspaces, as notes can refer to notes
+	// of other workspaces. If the current second is taken, the next free one
+	// is used instead. Workspaces are listed separately, as notes of all of
+	// them cannot be listed together if some Uids are already duplicated.
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+	taken := make(map[string]bool)
+	for _, ws := range foundWorkspaces {
+		uids, err := notes.NewFilesystemNoteRepository(ws.GetNotesPath()).List()
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot list notes in workspace %s", ws.GetName()))
+		}
+		for _, uid := range uids {
+			taken[uid] = true
+		}
+	}
+	isTaken := func(uid string) bool { return taken[uid] }
+	when := notes.FindFreeTime(self.Nowtime(), isTaken)
+
 	destinationDirPath := path.Join(self.ZettelkastenDir, self.WorkspaceName, workspaces.NotesDirName)
 	repo := notes.NewFilesystemNoteRepository(destinationDirPath)
-	notePath, err := repo.Put(newNote)
-	if err != nil {
-		return "", errors.Join(err, errors.New("Cannot save note"))
+	repo.Codec = codec
+	for attempt := 1; ; attempt++ {
+		newNote, err := self.createNote(when)
+		if err != nil {
+			return "", err
+		}
+		notePath, err := repo.Create(newNote)
+		// Note with the same Uid could have been created meanwhile by another
+		// process; it is never overwritten.
+		if errors.Is(err, notes.ErrNoteExists) && attempt < maxCreateAttempts {
+			when = notes.FindFreeTime(when.Add(time.Second), isTaken)
+			continue
+		}
+		if err != nil {
+			return "", errors.Join(err, errors.New("Cannot save note"))
+		}
+		return notePath, nil
+	}
+}
+
+func (self New) createNote(when time.Time) (notes.Note, error) {
+	newNote := notes.NewNote(when)
+	if self.TemplateName != "" {
+		content, err := self.readTemplate()
+		if err != nil {
+			return notes.Note{}, err
+		}
+		newNote, err = notes.NewNoteFromTemplate(when, self.WorkspaceName, content)
+		if err != nil {
+			return notes.Note{}, errors.Join(err, fmt.Errorf("Cannot use template '%s'", self.TemplateName))
+		}
+	}
+
+	if self.Title != "" {
+		newNote.Header.Title = self.Title
+	}
+	newNote.Header.Tags = append(newNote.Header.Tags, self.Tags...)
+	newNote.Header.Arrange()
+	newNote.Header.Tags = slices.Compact(newNote.Header.Tags)
+	body := strings.TrimSpace(self.Body)
+	if body != "" && newNote.Body != "" {
+		newNote.Body = newNote.Body + "\n\n" + body
+	} else if body != "" {
+		newNote.Body = body
+	}
+	return newNote, nil
+}
+
+func (self New) readTemplate() (string, error) {
+	// Template names must not lead out of templates directories.
+	if strings.ContainsAny(self.TemplateName, "/"+string(os.PathSeparator)) || strings.Contains(self.TemplateName, "..") {
+		return "", fmt.Errorf("Template name '%s' cannot contain path separators or '..'", self.TemplateName)
+	}
+	dirs := []string{path.Join(self.ZettelkastenDir, self.WorkspaceName, workspaces.TemplatesDirName)}
+	if self.TemplatesDir != "" {
+		dirs = append(dirs, self.TemplatesDir)
+	}
+	for _, dir := range dirs {
+		content, err := os.ReadFile(path.Join(dir, self.TemplateName+".md"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot read template '%s'", self.TemplateName))
+		}
+		return string(content), nil
 	}
-	return notePath, nil
+	return "", fmt.Errorf("Template '%s' does not exist", self.TemplateName)
 }
@@ -0,0 +1,255 @@
+package commands
+
+import "os"
+import "path"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+func TestNewFromTemplate(t *testing.T) {
+	// GIVEN
+	previousLocal := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = previousLocal })
+	tempDir := t.TempDir()
+	zkdir := path.Join(tempDir, "zkdir")
+	os.MkdirAll(zkdir, 0755)
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+
+	globalTemplatesDir := path.Join(tempDir, "config", "templates")
+	os.MkdirAll(globalTemplatesDir, 0755)
+	os.WriteFile(
+		path.Join(globalTemplatesDir, "book.md"),
+		[]byte("```toml\ntitle = \"Book\"\ntags = [\"book\"]\n```\n\n## Global"),
+		0644,
+	)
+	os.WriteFile(
+		path.Join(globalTemplatesDir, "travel.md"),
+		[]byte("## Itinerary {{.Date}}"),
+		0644,
+	)
+	workspaceTemplatesDir := path.Join(zkdir, "main", workspaces.TemplatesDirName)
+	os.MkdirAll(workspaceTemplatesDir, 0755)
+	os.WriteFile(
+		path.Join(workspaceTemplatesDir, "book.md"),
+		[]byte("```toml\ntitle = \"Book\"\ntags = [\"book\", \"main\"]\n```\n\n## Local"),
+		0644,
+	)
+
+	nowtime := func() time.Time { return time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC) }
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+
+	// WHEN
+	_, err = New{
+		ZettelkastenDir: zkdir,
+		WorkspaceName:   "main",
+		TemplateName:    "book",
+		TemplatesDir:    globalTemplatesDir,
+		Nowtime:         nowtime,
+	}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	book, err := repo.Get("20240315T123000Z")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"book", "main"}, book.Header.Tags)
+	assert.Equal(t, "## Local", book.Body)
+
+	// WHEN
+	nowtime = func() time.Time { return time.Date(2024, 3, 16, 8, 0, 0, 0, time.UTC) }
+	_, err = New{
+		ZettelkastenDir: zkdir,
+		WorkspaceName:   "main",
+		TemplateName:    "travel",
+		TemplatesDir:    globalTemplatesDir,
+		Nowtime:         nowtime,
+	}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	travel, err := repo.Get("20240316T080000Z")
+	assert.Nil(t, err)
+	assert.Equal(t, "## Itinerary 2024-03-16", travel.Body)
+
+	// WHEN
+	_, err = New{
+		ZettelkastenDir: zkdir,
+		WorkspaceName:   "main",
+		TemplateName:    "meeting",
+		TemplatesDir:    globalTemplatesDir,
+		Nowtime:         nowtime,
+	}.Run()
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Template 'meeting' does not exist")
+}
+
+func TestNewWithTitleTagsAndBody(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	zkdir := path.Join(tempDir, "zkdir")
+	os.MkdirAll(zkdir, 0755)
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+
+	templatesDir := path.Join(tempDir, "templates")
+	os.MkdirAll(templatesDir, 0755)
+	os.WriteFile(
+		path.Join(templatesDir, "book.md"),
+		[]byte("```toml\ntitle = \"Book\"\ntags = [\"book\"]\n```\n\n## Quotes"),
+		0644,
+	)
+	nowtime := func() time.Time { return time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC) }
+
+	// WHEN
+	_, err = New{
+		ZettelkastenDir: zkdir,
+		WorkspaceName:   "main",
+		TemplateName:    "book",
+		TemplatesDir:    templatesDir,
+		Title:           "Dune",
+		Tags:            []string{"Genre:SciFi", "book"},
+		Body:            "Fear is the mind-killer.\n",
+		Nowtime:         nowtime,
+	}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	actual, err := repo.Get("20240315T123000Z")
+	assert.Nil(t, err)
+	assert.Equal(t, "Dune", actual.Header.Title)
+	assert.Equal(t, []string{"book", "genre:scifi"}, actual.Header.Tags)
+	assert.Equal(t, "## Quotes\n\nFear is the mind-killer.", actual.Body)
+}
+
+func TestNewAvoidsUidCollisionsAcrossWorkspaces(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	for _, name := range []string{"main", "work"} {
+		err := workspaces.CreateWorkspace(zkdir, name)
+		assert.Nil(t, err)
+	}
+	nowtime := func() time.Time { return time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC) }
+	cmdNew := func(workspaceName string) New {
+		return New{ZettelkastenDir: zkdir, WorkspaceName: workspaceName, Nowtime: nowtime}
+	}
+
+	// WHEN
+	firstPath, firstErr := cmdNew("main").Run()
+	secondPath, secondErr := cmdNew("work").Run()
+	thirdPath, thirdErr := cmdNew("main").Run()
+
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Nil(t, thirdErr)
+	assert.Equal(t, path.Join(zkdir, "main", "notes", "20240315T123000Z.md"), firstPath)
+	assert.Equal(t, path.Join(zkdir, "work", "notes", "20240315T123001Z.md"), secondPath)
+	assert.Equal(t, path.Join(zkdir, "main", "notes", "20240315T123002Z.md"), thirdPath)
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "work", "notes"))
+	second, err := repo.Get("20240315T123001Z")
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-03-15T12:30:01+00:00", second.Header.Timestamp)
+}
+
+func TestNewArrangesTemplateTags(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	zkdir := path.Join(tempDir, "zkdir")
+	os.MkdirAll(zkdir, 0755)
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+
+	templatesDir := path.Join(tempDir, "templates")
+	os.MkdirAll(templatesDir, 0755)
+	os.WriteFile(
+		path.Join(templatesDir, "trip.md"),
+		[]byte("```toml\ntags = [\"Travel\", \"abroad\", \"travel\"]\n```\n"),
+		0644,
+	)
+	nowtime := func() time.Time { return time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC) }
+
+	// WHEN
+	_, err = New{
+		ZettelkastenDir: zkdir,
+		WorkspaceName:   "main",
+		TemplateName:    "trip",
+		TemplatesDir:    templatesDir,
+		Nowtime:         nowtime,
+	}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	actual, err := repo.Get("20240315T123000Z")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"abroad", "travel"}, actual.Header.Tags)
+}
+
+func TestNewRejectsTemplateNamesLeavingTemplatesDir(t *testing.T) {
+	testCases := []struct {
+		testName     string
+		templateName string
+	}{
+		{testName: "parent directory", templateName: "../secret"},
+		{testName: "nested directory", templateName: "private/book"},
+		{testName: "dots only", templateName: ".."},
+	}
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// GIVEN
+			tempDir := t.TempDir()
+			zkdir := path.Join(tempDir, "zkdir")
+			os.MkdirAll(zkdir, 0755)
+			err := workspaces.CreateWorkspace(zkdir, "main")
+			assert.Nil(t, err)
+			templatesDir := path.Join(tempDir, "templates")
+			os.MkdirAll(path.Join(templatesDir, "private"), 0755)
+			os.WriteFile(path.Join(tempDir, "secret.md"), []byte("Secret"), 0644)
+			os.WriteFile(path.Join(templatesDir, "private", "book.md"), []byte("Book"), 0644)
+			nowtime := func() time.Time { return time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC) }
+
+			// WHEN
+			_, err = New{
+				ZettelkastenDir: zkdir,
+				WorkspaceName:   "main",
+				TemplateName:    tc.templateName,
+				TemplatesDir:    templatesDir,
+				Nowtime:         nowtime,
+			}.Run()
+
+			// THEN
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "cannot contain path separators or '..'")
+		}
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestNewAvoidsUidCollisionsDespiteDuplicates(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	for _, name := range []string{"main", "work"} {
+		err := workspaces.CreateWorkspace(zkdir, name)
+		assert.Nil(t, err)
+		repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, name, workspaces.NotesDirName))
+		repo.Put(notes.NewNote(time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)))
+	}
+	mainRepo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	mainRepo.Put(notes.NewNote(time.Date(2024, 3, 15, 12, 30, 1, 0, time.UTC)))
+	nowtime := func() time.Time { return time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC) }
+
+	// WHEN
+	notePath, err := New{ZettelkastenDir: zkdir, WorkspaceName: "work", Nowtime: nowtime}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, path.Join(zkdir, "work", "notes", "20240315T123002Z.md"), notePath)
+}
@@ -0,0 +1,112 @@
+package commands
+
+import "errors"
+import "fmt"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// RenameTag carries required params to run command.
+type RenameTag struct {
+	ZettelkastenDir string
+	From            string
+	To              string
+}
+
+// Run replaces tag with a new one in all notes. It refuses to rename to a tag
+// which is already in use; MergeTags is meant for that. Tags are compared
+// lowercase, so renaming may only change case, e.g. `Topic:Go` to `topic:go`.
+func (self RenameTag) Run() (string, error) {
+	if self.From == "" || self.To == "" {
+		return "", errors.New("Both current and new tag must be provided")
+	}
+	if !strings.EqualFold(self.From, self.To) {
+		isUsed, err := isTagUsed(self.ZettelkastenDir, self.To)
+		if err != nil {
+			return "", err
+		}
+		if isUsed {
+			return "", fmt.Errorf("Tag '%s' is already in use. Merge tags instead", self.To)
+		}
+	}
+	modified, err := replaceTags(self.ZettelkastenDir, []string{self.From}, self.To)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Renamed tag in %d notes.", modified), nil
+}
+
+// MergeTags carries required params to run command.
+type MergeTags struct {
+	ZettelkastenDir string
+	From            []string
+	Into            string
+}
+
+// Run replaces all given tags with one in all notes.
+func (self MergeTags) Run() (string, error) {
+	if len(self.From) == 0 || self.Into == "" {
+		return "", errors.New("Tags to merge and the resulting tag must be provided")
+	}
+	modified, err := replaceTags(self.ZettelkastenDir, self.From, self.Into)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Merged tags in %d notes.", modified), nil
+}
+
+// DeleteTag carries required params to run command.
+type DeleteTag struct {
+	ZettelkastenDir string
+	Tag             string
+}
+
+// Run removes tag from all notes.
+func (self DeleteTag) Run() (string, error) {
+	if self.Tag == "" {
+		return "", errors.New("Tag to delete must be provided")
+	}
+	modified, err := replaceTags(self.ZettelkastenDir, []string{self.Tag}, "")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Deleted tag from %d notes.", modified), nil
+}
+
+func replaceTags(zettelkastenDir string, replaced []string, replacement string) (int, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(zettelkastenDir)
+	if err != nil {
+		return 0, errors.Join(err, errors.New("Could not change tags because no workspaces were found"))
+	}
+
+	modified := 0
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		modifiedInWorkspace, err := notes.ReplaceTags(repository, replaced, replacement)
+		modified += modifiedInWorkspace
+		if err != nil {
+			return modified, errors.Join(err, fmt.Errorf("Could not change tags in workspace %s", ws.GetName()))
+		}
+	}
+	return modified, nil
+}
+
+func isTagUsed(zettelkastenDir string, tag string) (bool, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(zettelkastenDir)
+	if err != nil {
+		return false, errors.Join(err, errors.New("Could not check tags because no workspaces were found"))
+	}
+
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		index, err := notes.UpdateInvertedIndexFile(repository, ws.GetInvertedIndexPath())
+		if err != nil {
+			return false, errors.Join(err, fmt.Errorf("Cannot index notes in workspace %s", ws.GetName()))
+		}
+		if len(index.Tagged(tag)) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,50 @@
+package commands
+
+import "path"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+func TestRenameTag(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	repo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	note1 := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note1.Header.Tags = []string{"Topic:Go"}
+	repo.Put(note1)
+	note2 := notes.NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	note2.Header.Tags = []string{"topic:rust"}
+	repo.Put(note2)
+
+	testCases := []struct {
+		testName       string
+		from           string
+		to             string
+		expectedOutput string
+		expectedErr    bool
+	}{
+		{"Tag in use", "Topic:Go", "topic:rust", "", true},
+		{"Only case changes", "Topic:Go", "topic:go", "Renamed tag in 1 notes.", false},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			output, err := RenameTag{ZettelkastenDir: zkdir, From: tc.from, To: tc.to}.Run()
+
+			// THEN
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedOutput, output)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+	renamed, _ := repo.Get(note1.Header.Uid)
+	assert.Equal(t, []string{"topic:go"}, renamed.Header.Tags)
+}
@@ -118,6 +118,62 @@ func TestLinkTwoNotes(t *testing.T) {
 	assert.Equal(t, []string{note1.Header.Uid}, note2.Header.RefersTo)
 }
 
+func TestLinkNotesInDifferentWorkspaces(t *testing.T) {
+	zkdir := t.TempDir()
+	for _, wsname := range []string{"main", "work"} {
+		os.MkdirAll(path.Join(zkdir, wsname, "notes"), 0777)
+	}
+
+	// Create note in each workspace.
+	cmdNew := commands.New{
+		ZettelkastenDir: zkdir,
+		WorkspaceName:   "main",
+		Nowtime:         func() time.Time { return time.Date(2024, 1, 1, 1, 1, 1, 1, time.UTC) },
+	}
+	_, err := cmdNew.Run()
+	assert.Nil(t, err)
+
+	cmdNew = commands.New{
+		ZettelkastenDir: zkdir,
+		WorkspaceName:   "work",
+		Nowtime:         func() time.Time { return time.Date(2024, 2, 2, 2, 2, 2, 2, time.UTC) },
+	}
+	_, err = cmdNew.Run()
+	assert.Nil(t, err)
+
+	// Refer to note in main workspace from the one in work.
+	mainRepo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", "notes"))
+	workRepo := notes.NewFilesystemNoteRepository(path.Join(zkdir, "work", "notes"))
+	note1, _ := mainRepo.Get("20240101T010101Z")
+	note2, _ := workRepo.Get("20240202T020202Z")
+	note2.Body = fmt.Sprintf("Refers to [[%s]]", note1.Header.Uid)
+	_, err = workRepo.Put(note2)
+	assert.Nil(t, err)
+
+	// Isolated linking must not see note from the other workspace.
+	cmdLink := commands.Link{
+		ZettelkastenDir: zkdir,
+		Isolated:        true,
+	}
+	_, err = cmdLink.Run()
+	assert.Nil(t, err)
+
+	note1, _ = mainRepo.Get(note1.Header.Uid)
+	assert.Equal(t, []string{}, note1.Header.ReferredFrom)
+
+	cmdLink = commands.Link{
+		ZettelkastenDir: zkdir,
+	}
+	out, err := cmdLink.Run()
+	assert.Nil(t, err)
+	assert.Equal(t, "Updated 1 notes, 1 unchanged.", out)
+
+	note1, _ = mainRepo.Get(note1.Header.Uid)
+	note2, _ = workRepo.Get(note2.Header.Uid)
+	assert.Equal(t, []string{note2.Header.Uid}, note1.Header.ReferredFrom)
+	assert.Equal(t, []string{note1.Header.Uid}, note2.Header.RefersTo)
+}
+
 // TestInitializeAddCommitRemove verifies whole lifecycle of a repository and a
 // note:
 // 1. Empty git repo initialization
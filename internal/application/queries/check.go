@@ -0,0 +1,213 @@
+package queries
+
+import "errors"
+import "fmt"
+import "os"
+import "path"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/indices"
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// checkProblem is a serialisable problem found by a check, located in a file
+// relative to zettelkasten directory.
+type checkProblem struct {
+	Path string `json:"path"`
+	// Line counts from 1, or is 0 if the problem concerns the whole file.
+	Line    int    `json:"line,omitempty"`
+	Problem string `json:"problem"`
+}
+
+// String formats checkProblem as `path[:line]: problem`.
+func (self checkProblem) String() string {
+	location := self.Path
+	if self.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, self.Line)
+	}
+	return location + ": " + self.Problem
+}
+
+// reportProblems prints problems found by a check, followed by summary. If
+// there are none, text of success is printed instead. If there are any,
+// summary is returned as an error, so checks can guard e.g. pre-commit hooks;
+// in text format the whole report is the error, otherwise serialised problems
+// are printed nevertheless.
+func reportProblems(format OutputFormat, problems []checkProblem, summary string, success string) (string, error) {
+	if len(problems) == 0 {
+		return renderList(format, []string{success}, problems)
+	}
+	if format.IsStructured() {
+		output, err := renderList(format, []string{}, problems)
+		if err != nil {
+			return "", err
+		}
+		return output, errors.New(summary)
+	}
+	lines := []string{}
+	for _, problem := range problems {
+		lines = append(lines, problem.String())
+	}
+	lines = append(lines, summary)
+	return "", errors.New(strings.Join(lines, "\n"))
+}
+
+// CheckLinks carries required params to look for references to notes which
+// do not exist in any workspace.
+type CheckLinks struct {
+	ZettelkastenDir string
+	Output          OutputFormat
+}
+
+// Run reports every dangling reference as `path:line: target`, where path is
+// relative to ZettelkastenDir. If any are found, report is returned as an
+// error, see reportProblems.
+func (self CheckLinks) Run() (string, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+
+	allNotes, err := workspaces.GetNoteRepository(self.ZettelkastenDir)
+	if err != nil {
+		return "", err
+	}
+	existing, err := allNotes.List()
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot list notes"))
+	}
+
+	problems := []checkProblem{}
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		dangling, err := notes.FindDanglingReferences(repository, existing)
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot check links in workspace %s", ws.GetName()))
+		}
+		for _, ref := range dangling {
+			problems = append(problems, checkProblem{
+				Path:    path.Join(ws.GetName(), workspaces.NotesDirName, ref.Source+".md"),
+				Line:    ref.Line,
+				Problem: "reference to missing note " + ref.Target,
+			})
+		}
+	}
+
+	summary := fmt.Sprintf("Found %d dangling references.", len(problems))
+	return reportProblems(self.Output, problems, summary, "No dangling references.")
+}
+
+// CheckNotes carries required params to look for notes which cannot be
+// loaded.
+type CheckNotes struct {
+	ZettelkastenDir string
+	Output          OutputFormat
+}
+
+// Run reports every malformed note as `path:line: problem`, where path is
+// relative to ZettelkastenDir. If any are found, report is returned as an
+// error, see reportProblems.
+func (self CheckNotes) Run() (string, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+
+	problems := []checkProblem{}
+	checked := 0
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		uids, err := repository.List()
+		if err != nil {
+			return "", errors.Join(err, errors.New("Cannot list notes"))
+		}
+		checked += len(uids)
+		malformed, err := notes.FindMalformedNotes(repository)
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot check notes in workspace %s", ws.GetName()))
+		}
+		for _, note := range malformed {
+			problems = append(problems, checkProblem{
+				Path:    path.Join(ws.GetName(), workspaces.NotesDirName, note.Uid+".md"),
+				Line:    note.Err.Line,
+				Problem: strings.ReplaceAll(note.Err.Err.Error(), "\n", ": "),
+			})
+		}
+	}
+
+	summary := fmt.Sprintf("Found %d malformed notes.", len(problems))
+	return reportProblems(self.Output, problems, summary, fmt.Sprintf("Checked %d notes, no problems found.", checked))
+}
+
+// CheckIndices carries required params to validate index files of all
+// workspaces.
+type CheckIndices struct {
+	ZettelkastenDir string
+	Output          OutputFormat
+}
+
+// Run reports index files which cannot be read, have invalid header or refer
+// to notes which do not exist, as `path[:line]: problem`, where path is
+// relative to ZettelkastenDir. If any are found, report is returned as an
+// error, see reportProblems.
+func (self CheckIndices) Run() (string, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+
+	allNotes, err := workspaces.GetNoteRepository(self.ZettelkastenDir)
+	if err != nil {
+		return "", err
+	}
+	existing, err := allNotes.List()
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot list notes"))
+	}
+	isExisting := make(map[string]bool)
+	for _, uid := range existing {
+		isExisting[uid] = true
+	}
+
+	problems := []checkProblem{}
+	checked := 0
+	for _, ws := range foundWorkspaces {
+		names, err := indices.ListIndexFiles(ws.GetIndexPath())
+		if err != nil {
+			return "", err
+		}
+		for _, name := range names {
+			checked++
+			indexPath := path.Join(ws.GetName(), workspaces.IndexDirName, name+".md")
+			_, err := indices.GetIndexFile(ws.GetIndexPath(), name)
+			if err != nil {
+				problems = append(problems, checkProblem{
+					Path:    indexPath,
+					Problem: strings.ReplaceAll(err.Error(), "\n", ": "),
+				})
+				continue
+			}
+			content, err := os.ReadFile(path.Join(ws.GetIndexPath(), name+".md"))
+			if err != nil {
+				return "", errors.Join(err, fmt.Errorf("Cannot read index file %s", indexPath))
+			}
+			locations, err := notes.LocateReferences(string(content))
+			if err != nil {
+				return "", errors.Join(err, fmt.Errorf("Cannot find references in index file %s", indexPath))
+			}
+			for _, location := range locations {
+				if !isExisting[location.Uid] {
+					problems = append(problems, checkProblem{
+						Path:    indexPath,
+						Line:    location.Line,
+						Problem: "reference to missing note " + location.Uid,
+					})
+				}
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("Found %d problems in index files.", len(problems))
+	success := fmt.Sprintf("Checked %d index files, no problems found.", checked)
+	return reportProblems(self.Output, problems, summary, success)
+}
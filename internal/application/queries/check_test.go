@@ -0,0 +1,88 @@
+package queries
+
+import "path"
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// createCheckedZettelkasten creates workspace with a note referring to missing
+// note and a note without header.
+func createCheckedZettelkasten(t *testing.T) (string, *notes.FilesystemNoteRepository) {
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	repository := notes.NewFilesystemNoteRepository(path.Join(zkdir, "main", workspaces.NotesDirName))
+	repository.PutRaw(
+		"20240101T000000Z",
+		"```toml\nuid = \"20240101T000000Z\"\n```\n\nSee [[20240102T000000Z]].\nAlso [[20300101T000000Z]].\n",
+	)
+	repository.PutRaw("20240102T000000Z", "No header, but [[20300101T000000Z]].\n")
+	return zkdir, repository
+}
+
+func TestCheckLinks(t *testing.T) {
+	// GIVEN
+	zkdir, repository := createCheckedZettelkasten(t)
+
+	// WHEN
+	output, err := CheckLinks{ZettelkastenDir: zkdir}.Run()
+
+	// THEN
+	assert.Equal(t, "", output)
+	assert.NotNil(t, err)
+	expected := "main/notes/20240101T000000Z.md:6: reference to missing note 20300101T000000Z\n" +
+		"Found 1 dangling references."
+	assert.Equal(t, expected, err.Error())
+
+	// WHEN
+	repository.PutRaw("20240101T000000Z", "```toml\nuid = \"20240101T000000Z\"\n```\n\nSee [[20240102T000000Z]].\n")
+	output, err = CheckLinks{ZettelkastenDir: zkdir}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "No dangling references.", output)
+}
+
+func TestCheckLinksAsJSON(t *testing.T) {
+	// GIVEN
+	zkdir, _ := createCheckedZettelkasten(t)
+
+	// WHEN
+	output, err := CheckLinks{ZettelkastenDir: zkdir, Output: JSONOutput}.Run()
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, "Found 1 dangling references.", err.Error())
+	expected := `[
+  {
+    "path": "main/notes/20240101T000000Z.md",
+    "line": 6,
+    "problem": "reference to missing note 20300101T000000Z"
+  }
+]`
+	assert.Equal(t, expected, output)
+}
+
+func TestCheckNotes(t *testing.T) {
+	// GIVEN
+	zkdir, repository := createCheckedZettelkasten(t)
+
+	// WHEN
+	output, err := CheckNotes{ZettelkastenDir: zkdir}.Run()
+
+	// THEN
+	assert.Equal(t, "", output)
+	assert.NotNil(t, err)
+	assert.Equal(t, "main/notes/20240102T000000Z.md:1: Note has no header\nFound 1 malformed notes.", err.Error())
+
+	// WHEN
+	repository.PutRaw("20240102T000000Z", "```toml\nuid = \"20240102T000000Z\"\n```\n")
+	output, err = CheckNotes{ZettelkastenDir: zkdir}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "Checked 2 notes, no problems found.", output)
+}
@@ -3,7 +3,8 @@ package queries
 import "errors"
 import "fmt"
 import "reflect"
-import "strings"
+import "text/template"
+import "time"
 
 import "github.com/radiand/zettelkasten/internal/common"
 import "github.com/radiand/zettelkasten/internal/config"
@@ -14,7 +15,31 @@ import "github.com/radiand/zettelkasten/internal/workspaces"
 type Get struct {
 	ConfigPath  string
 	ProvidePath bool
-	Query       []string
+	TagQuery    string
+	// MinAge leaves only notes created at least that long ago.
+	MinAge  time.Duration
+	Nowtime func() time.Time
+	// Depth limits how many links away neighbours can be.
+	Depth int
+	// Long prints notes in columns: UID, workspace, title, timestamp, tags.
+	Long bool
+	// Format is a Go template printing each listed note, e.g.
+	// `{{.Uid}} {{.Title}}`. Takes precedence over Long.
+	Format string
+	// Sort orders listed notes by timestamp, title or uid.
+	Sort string
+	// Since and Until limit listed notes to ones created in given range. Each
+	// is a date, RFC3339 time or duration back from now, e.g. 7d. Until date
+	// includes the whole day.
+	Since string
+	Until string
+	// Today limits listed notes to ones created since local midnight.
+	Today bool
+	// Where limits listed notes to ones with custom header fields of given
+	// values, each given as `name=value`.
+	Where  []string
+	Output OutputFormat
+	Query  []string
 }
 
 // Run executes the command.
@@ -26,40 +51,49 @@ func (self Get) Run() (string, error) {
 	}
 
 	if len(self.Query) == 0 {
-		return "", errors.New("Query must specify resource (available: config, note, workspace)")
+		return "", errors.New("Query must specify resource (available: config, neighbours, note, notes, orphans, path, workspace)")
 	}
 
 	switch self.Query[0] {
 	case "config":
-		return handleConfigQuery(configObj, self.Query)
+		return self.handleConfigQuery(configObj)
 	case "note":
-		return handleNoteQuery(configObj, self.Query, self.ProvidePath)
+		return self.handleNoteQuery(configObj)
 	case "notes":
-		return handleNotesQuery(configObj, self.Query, self.ProvidePath)
+		return self.handleNotesQuery(configObj)
+	case "orphans":
+		return self.handleOrphansQuery(configObj)
+	case "neighbours":
+		return self.handleNeighboursQuery(configObj)
+	case "path":
+		return self.handlePathQuery(configObj)
 	case "workspace", "workspaces":
-		return handleWorkspaceQuery(configObj, self.Query, self.ProvidePath)
+		return self.handleWorkspaceQuery(configObj)
 	}
 
 	return "", fmt.Errorf("Resource '%s' is not supported", self.Query[0])
 }
 
-func handleConfigQuery(cfg config.Config, query []string) (string, error) {
-	if len(query) < 2 {
+func (self Get) handleConfigQuery(cfg config.Config) (string, error) {
+	if len(self.Query) < 2 {
+		if self.Output.IsStructured() {
+			return renderSingle(self.Output, "", cfg)
+		}
 		return "", errors.New("Query must specify config field to be read")
 	}
-	value := reflect.ValueOf(cfg).FieldByName(query[1])
+	value := reflect.ValueOf(cfg).FieldByName(self.Query[1])
 	if !value.IsValid() {
-		return "", fmt.Errorf("No key with name '%s", query)
+		return "", fmt.Errorf("No key with name '%s", self.Query)
 	}
-	return fmt.Sprintf("%v", value), nil
+	return renderSingle(self.Output, fmt.Sprintf("%v", value), value.Interface())
 }
 
-func handleNoteQuery(cfg config.Config, query []string, providePath bool) (string, error) {
-	if len(query) < 2 {
+func (self Get) handleNoteQuery(cfg config.Config) (string, error) {
+	if len(self.Query) < 2 {
 		return "", errors.New("Query must contain note UID")
 	}
 
-	uid := query[1]
+	uid := self.Query[1]
 
 	if !notes.GetUidRegexp().MatchString(uid) {
 		return "", fmt.Errorf("%s is not valid note UID", uid)
@@ -70,27 +104,76 @@ func handleNoteQuery(cfg config.Config, query []string, providePath bool) (strin
 	for _, ws := range foundWorkspaces {
 		noteRepo := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
 		noteObj, err := noteRepo.Get(uid)
+		var parseErr *notes.ParseError
+		if errors.As(err, &parseErr) {
+			// Malformed note can still be opened, e.g. to be fixed.
+			if self.ProvidePath {
+				return renderSingle(self.Output, parseErr.Path, parseErr.Path)
+			}
+			return "", err
+		}
 		if err != nil {
 			continue
 		}
-		if providePath {
-			return noteRepo.GetNotePath(noteObj.Header.Uid), nil
+		notePath := noteRepo.GetNotePath(noteObj.Header.Uid)
+		if self.ProvidePath {
+			return renderSingle(self.Output, notePath, notePath)
 		}
-		marshalled, err := noteObj.ToToml()
+		// Note is printed as it is in file, in its own header format.
+		content, err := noteRepo.GetRaw(uid)
 		if err != nil {
-			return "", fmt.Errorf("%s could not be marshalled", noteObj.Header.Uid)
+			return "", errors.Join(err, fmt.Errorf("Cannot read note with UID '%s'", uid))
 		}
-		return marshalled, nil
+		record := noteRecord{Workspace: ws.GetName(), Path: notePath, Note: noteObj}
+		return renderSingle(self.Output, content, record)
 	}
 	return "", fmt.Errorf("Could not find note with UID %s", uid)
 }
 
-func handleNotesQuery(cfg config.Config, query []string, providePath bool) (string, error) {
+func (self Get) handleNotesQuery(cfg config.Config) (string, error) {
 	var selectedWorkspace *string
-	if len(query) > 1 {
-		selectedWorkspace = &query[1]
+	if len(self.Query) > 1 {
+		selectedWorkspace = &self.Query[1]
 	}
 
+	var tagQuery *notes.TagQuery
+	if self.TagQuery != "" {
+		parsed, err := notes.ParseTagQuery(self.TagQuery)
+		if err != nil {
+			return "", err
+		}
+		tagQuery = &parsed
+	}
+
+	format := self.Format
+	if format == "" && self.Long {
+		format = longListingFormat
+	}
+	var tmpl *template.Template
+	if format != "" {
+		parsed, err := parseListingTemplate(format)
+		if err != nil {
+			return "", err
+		}
+		tmpl = parsed
+	}
+	since, until, err := self.creationRange()
+	if err != nil {
+		return "", err
+	}
+	isDateFiltered := !since.IsZero() || !until.IsZero()
+	schema := notes.FieldSchema(cfg.Fields)
+	err = schema.Validate()
+	if err != nil {
+		return "", errors.Join(err, errors.New("Invalid [fields] in config"))
+	}
+	filters, err := parseFieldFilters(self.Where, schema)
+	if err != nil {
+		return "", err
+	}
+	needsHeaders := self.Output.IsStructured() || tmpl != nil || self.Sort != "" || isDateFiltered ||
+		len(filters) > 0
+
 	expandedRootPath := common.ExpandHomeDir(cfg.ZettelkastenDir)
 	foundWorkspaces, err := workspaces.GetWorkspaces(expandedRootPath)
 
@@ -98,7 +181,7 @@ func handleNotesQuery(cfg config.Config, query []string, providePath bool) (stri
 		return "", fmt.Errorf("Could not find any workspaces in %s", expandedRootPath)
 	}
 
-	lines := []string{}
+	records := []headerRecord{}
 	for _, ws := range foundWorkspaces {
 		if selectedWorkspace != nil && ws.GetName() != *selectedWorkspace {
 			continue
@@ -108,19 +191,319 @@ func handleNotesQuery(cfg config.Config, query []string, providePath bool) (stri
 		if err != nil {
 			return "", fmt.Errorf("Cannot list notes in workspace %s", ws.GetName())
 		}
+		if tagQuery != nil {
+			uids, err = filterByTags(noteRepo, ws.GetInvertedIndexPath(), uids, tagQuery)
+			if err != nil {
+				return "", errors.Join(err, fmt.Errorf("Cannot filter notes in workspace %s", ws.GetName()))
+			}
+		}
 		for _, uid := range uids {
-			if providePath {
-				lines = append(lines, noteRepo.GetNotePath(uid))
-			} else {
-				lines = append(lines, uid)
+			if !needsHeaders {
+				records = append(records, headerRecord{
+					Workspace: ws.GetName(),
+					Path:      noteRepo.GetNotePath(uid),
+					Header:    notes.Header{Uid: uid},
+				})
+				continue
+			}
+			record, err := newHeaderRecord(noteRepo, ws.GetName(), uid)
+			var parseErr *notes.ParseError
+			if errors.As(err, &parseErr) {
+				// Malformed notes are reported by `check notes`.
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			if !matchesFieldFilters(record.Header, filters) {
+				continue
+			}
+			if isDateFiltered {
+				created, err := record.Header.GetTime()
+				if err != nil {
+					return "", errors.Join(err, fmt.Errorf("Cannot read age of note with UID '%s'", uid))
+				}
+				if created.Before(since) || (!until.IsZero() && !created.Before(until)) {
+					continue
+				}
+			}
+			records = append(records, record)
+		}
+	}
+
+	err = sortHeaderRecords(records, self.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	for _, record := range records {
+		switch {
+		case tmpl != nil:
+			line, err := executeListingTemplate(tmpl, record, schema)
+			if err != nil {
+				return "", err
 			}
+			lines = append(lines, line)
+		case self.ProvidePath:
+			lines = append(lines, record.Path)
+		default:
+			lines = append(lines, record.Header.Uid)
 		}
 	}
-	return strings.Join(lines, "\n"), nil
+	return renderList(self.Output, lines, records)
+}
+
+// creationRange resolves Since, Until and Today to bounds of creation time of
+// listed notes: since is inclusive, until is exclusive. Zero time stands for
+// no bound.
+func (self Get) creationRange() (time.Time, time.Time, error) {
+	var since, until time.Time
+	if self.Today && self.Since != "" {
+		return since, until, errors.New("Today cannot be combined with Since")
+	}
+	if !self.Today && self.Since == "" && self.Until == "" {
+		return since, until, nil
+	}
+	now := self.Nowtime().Local()
+	if self.Today {
+		since = common.StartOfDay(now)
+	}
+	if self.Since != "" {
+		moment, _, err := common.ParseMoment(self.Since, now)
+		if err != nil {
+			return since, until, errors.Join(err, errors.New("Invalid beginning of date range"))
+		}
+		since = moment
+	}
+	if self.Until != "" {
+		moment, isDay, err := common.ParseMoment(self.Until, now)
+		if err != nil {
+			return since, until, errors.Join(err, errors.New("Invalid end of date range"))
+		}
+		if isDay {
+			moment = moment.AddDate(0, 0, 1)
+		}
+		until = moment
+	}
+	return since, until, nil
+}
+
+// filterByTags leaves only uids of notes with tags matching the query. Tags
+// are read from inverted index, so notes do not have to be parsed one by one.
+// Malformed notes are not indexed, so they never match.
+func filterByTags(
+	noteRepo *notes.FilesystemNoteRepository,
+	indexPath string,
+	uids []string,
+	tagQuery *notes.TagQuery,
+) ([]string, error) {
+	index, err := notes.UpdateInvertedIndexFile(noteRepo, indexPath)
+	if err != nil {
+		return []string{}, err
+	}
+	filtered := []string{}
+	for _, uid := range uids {
+		indexed, isIndexed := index.Notes[uid]
+		if isIndexed && tagQuery.Matches(indexed.Tags) {
+			filtered = append(filtered, uid)
+		}
+	}
+	return filtered, nil
+}
+
+func (self Get) handleOrphansQuery(cfg config.Config) (string, error) {
+	if len(self.Query) > 2 {
+		return "", errors.New("Query accepts at most one workspace")
+	}
+	var selectedWorkspace *string
+	if len(self.Query) > 1 {
+		selectedWorkspace = &self.Query[1]
+	}
+
+	expandedRootPath := common.ExpandHomeDir(cfg.ZettelkastenDir)
+
+	// Orphans are sought among all workspaces, because notes can be linked
+	// with notes of other workspaces.
+	indexed, err := workspaces.GetIndexedWorkspaces(expandedRootPath)
+	if err != nil {
+		return "", err
+	}
+	repositories, err := getWorkspaceRepositories(expandedRootPath)
+	if err != nil {
+		return "", err
+	}
+	if selectedWorkspace != nil && repositories[*selectedWorkspace] == nil {
+		return "", fmt.Errorf("Workspace %s does not exist", *selectedWorkspace)
+	}
+
+	lines := []string{}
+	records := []headerRecord{}
+	for _, uid := range notes.FindOrphans(indexed) {
+		indexedNote, workspaceName, _ := indexed.Get(uid)
+		if selectedWorkspace != nil && workspaceName != *selectedWorkspace {
+			continue
+		}
+		if self.MinAge > 0 {
+			header := notes.Header{Uid: uid, Timestamp: indexedNote.Timestamp}
+			created, err := header.GetTime()
+			if err != nil {
+				return "", errors.Join(err, fmt.Errorf("Cannot read age of note with UID '%s'", uid))
+			}
+			if self.Nowtime().Sub(created) < self.MinAge {
+				continue
+			}
+		}
+		notePath := repositories[workspaceName].GetNotePath(uid)
+		if self.ProvidePath {
+			lines = append(lines, notePath)
+		} else {
+			lines = append(lines, uid)
+		}
+		if self.Output.IsStructured() {
+			record, err := newHeaderRecord(repositories[workspaceName], workspaceName, uid)
+			if err != nil {
+				return "", err
+			}
+			records = append(records, record)
+		}
+	}
+	return renderList(self.Output, lines, records)
+}
+
+// neighbourRecord is a serialisable neighbour of a note.
+type neighbourRecord struct {
+	headerRecord
+	Distance int `json:"distance"`
+}
+
+func (self Get) handleNeighboursQuery(cfg config.Config) (string, error) {
+	if len(self.Query) != 2 {
+		return "", errors.New("Query must contain exactly one note UID")
+	}
+	uid := self.Query[1]
+	if !notes.GetUidRegexp().MatchString(uid) {
+		return "", fmt.Errorf("%s is not valid note UID", uid)
+	}
+	if self.Depth < 1 {
+		return "", errors.New("Depth must be at least 1")
+	}
+
+	expandedRootPath := common.ExpandHomeDir(cfg.ZettelkastenDir)
+	indexed, err := workspaces.GetIndexedWorkspaces(expandedRootPath)
+	if err != nil {
+		return "", err
+	}
+	neighbours, err := notes.FindNeighbours(indexed, uid, self.Depth)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Cannot find neighbours of note with UID %s", uid))
+	}
+	repositories, err := getWorkspaceRepositories(expandedRootPath)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	records := []neighbourRecord{}
+	for _, neighbour := range neighbours {
+		_, workspaceName, _ := indexed.Get(neighbour.Uid)
+		notePath := repositories[workspaceName].GetNotePath(neighbour.Uid)
+		if self.ProvidePath {
+			lines = append(lines, notePath)
+		} else {
+			lines = append(lines, fmt.Sprintf("%s\t%d\t%s", neighbour.Uid, neighbour.Distance, neighbour.Title))
+		}
+		if self.Output.IsStructured() {
+			record, err := newHeaderRecord(repositories[workspaceName], workspaceName, neighbour.Uid)
+			if err != nil {
+				return "", err
+			}
+			records = append(records, neighbourRecord{headerRecord: record, Distance: neighbour.Distance})
+		}
+	}
+	return renderList(self.Output, lines, records)
+}
+
+func (self Get) handlePathQuery(cfg config.Config) (string, error) {
+	if len(self.Query) != 3 {
+		return "", errors.New("Query must contain exactly two note UIDs")
+	}
+	from, to := self.Query[1], self.Query[2]
+	for _, uid := range []string{from, to} {
+		if !notes.GetUidRegexp().MatchString(uid) {
+			return "", fmt.Errorf("%s is not valid note UID", uid)
+		}
+	}
+
+	expandedRootPath := common.ExpandHomeDir(cfg.ZettelkastenDir)
+	indexed, err := workspaces.GetIndexedWorkspaces(expandedRootPath)
+	if err != nil {
+		return "", err
+	}
+	if _, _, ok := indexed.Get(to); !ok {
+		return "", fmt.Errorf("Note with UID '%s' does not exist", to)
+	}
+	path, err := notes.FindPath(indexed, from, to)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Cannot find path from %s to %s", from, to))
+	}
+	if len(path) == 0 {
+		return "", fmt.Errorf("Notes %s and %s are not linked", from, to)
+	}
+	repositories, err := getWorkspaceRepositories(expandedRootPath)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	records := []headerRecord{}
+	for _, uid := range path {
+		indexedNote, workspaceName, _ := indexed.Get(uid)
+		notePath := repositories[workspaceName].GetNotePath(uid)
+		if self.ProvidePath {
+			lines = append(lines, notePath)
+		} else {
+			lines = append(lines, fmt.Sprintf("%s\t%s", uid, indexedNote.Title))
+		}
+		if self.Output.IsStructured() {
+			record, err := newHeaderRecord(repositories[workspaceName], workspaceName, uid)
+			if err != nil {
+				return "", err
+			}
+			records = append(records, record)
+		}
+	}
+	return renderList(self.Output, lines, records)
+}
+
+// getWorkspaceRepositories creates repositories of notes of all workspaces,
+// by names of workspaces.
+func getWorkspaceRepositories(rootPath string) (map[string]*notes.FilesystemNoteRepository, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("Could not find any workspaces in %s", rootPath)
+	}
+	repositories := make(map[string]*notes.FilesystemNoteRepository)
+	for _, ws := range foundWorkspaces {
+		repositories[ws.GetName()] = notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+	}
+	return repositories, nil
+}
+
+func newHeaderRecord(noteRepo *notes.FilesystemNoteRepository, workspaceName string, uid string) (headerRecord, error) {
+	noteObj, err := noteRepo.Get(uid)
+	if err != nil {
+		return headerRecord{}, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+	}
+	return headerRecord{
+		Workspace: workspaceName,
+		Path:      noteRepo.GetNotePath(uid),
+		Header:    noteObj.Header,
+	}, nil
 }
 
-func handleWorkspaceQuery(cfg config.Config, query []string, providePath bool) (string, error) {
-	if len(query) > 1 {
+func (self Get) handleWorkspaceQuery(cfg config.Config) (string, error) {
+	if len(self.Query) > 1 {
 		return "", errors.New("Querying workspaces does not accept additional arguments")
 	}
 
@@ -133,10 +516,10 @@ func handleWorkspaceQuery(cfg config.Config, query []string, providePath bool) (
 
 	lines := []string{}
 	for _, ws := range foundWorkspaces {
-		if providePath {
+		if self.ProvidePath {
 			lines = append(lines, ws.GetWorkspacePath())
 		}
 		lines = append(lines, ws.GetName())
 	}
-	return strings.Join(lines, "\n"), nil
+	return renderList(self.Output, lines, foundWorkspaces)
 }
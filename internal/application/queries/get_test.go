@@ -0,0 +1,174 @@
+package queries
+
+import "os"
+import "path"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/config"
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// createZettelkasten creates workspaces of given names and config pointing to
+// them. Returns path of config and repositories of workspaces.
+func createZettelkasten(t *testing.T, names ...string) (string, map[string]*notes.FilesystemNoteRepository) {
+	tempDir := t.TempDir()
+	zkdir := path.Join(tempDir, "zkdir")
+	os.MkdirAll(zkdir, 0755)
+	repositories := make(map[string]*notes.FilesystemNoteRepository)
+	for _, name := range names {
+		err := workspaces.CreateWorkspace(zkdir, name)
+		assert.Nil(t, err)
+		repositories[name] = notes.NewFilesystemNoteRepository(path.Join(zkdir, name, workspaces.NotesDirName))
+	}
+	configPath := path.Join(tempDir, "config.toml")
+	cfg := config.NewConfig()
+	cfg.ZettelkastenDir = zkdir
+	err := config.PutConfigToFile(configPath, cfg)
+	assert.Nil(t, err)
+	return configPath, repositories
+}
+
+func TestGetOrphans(t *testing.T) {
+	// GIVEN
+	configPath, repositories := createZettelkasten(t, "main", "work")
+	linked := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	repositories["main"].Put(linked)
+	referring := notes.NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	referring.Body = "See [[20240101T000000Z]]."
+	repositories["work"].Put(referring)
+	selfReferring := notes.NewNote(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	selfReferring.Body = "See [[20240103T000000Z]] and [[20300101T000000Z]]."
+	repositories["main"].Put(selfReferring)
+	fresh := notes.NewNote(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC))
+	repositories["work"].Put(fresh)
+	nowtime := func() time.Time { return time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC) }
+
+	testCases := []struct {
+		testName       string
+		query          []string
+		minAge         time.Duration
+		expectedOutput string
+		expectedErr    bool
+	}{
+		{"All workspaces", []string{"orphans"}, 0, "20240103T000000Z\n20240110T000000Z", false},
+		{"Single workspace", []string{"orphans", "work"}, 0, "20240110T000000Z", false},
+		{"Old enough", []string{"orphans"}, 48 * time.Hour, "20240103T000000Z", false},
+		{"Missing workspace", []string{"orphans", "mian"}, 0, "", true},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			output, err := Get{ConfigPath: configPath, Query: tc.query, MinAge: tc.minAge, Nowtime: nowtime}.Run()
+
+			// THEN
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedOutput, output)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestGetNotesListing(t *testing.T) {
+	// GIVEN
+	configPath, repositories := createZettelkasten(t, "main", "work")
+	beta := notes.NewNote(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	beta.Header.Title = "beta"
+	beta.Header.Tags = []string{"book", "topic:go"}
+	repositories["main"].Put(beta)
+	gamma := notes.NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	gamma.Header.Title = "gamma"
+	gamma.Header.Extra = map[string]any{"status": "draft"}
+	repositories["main"].Put(gamma)
+	alpha := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	alpha.Header.Title = "Alpha"
+	repositories["work"].Put(alpha)
+
+	testCases := []struct {
+		testName       string
+		cmd            Get
+		expectedOutput string
+		expectedErr    bool
+	}{
+		{
+			"Long",
+			Get{Long: true},
+			"20240102T000000Z\tmain\tgamma\t2024-01-02T00:00:00+00:00\t\n" +
+				"20240103T000000Z\tmain\tbeta\t2024-01-03T00:00:00+00:00\tbook,topic:go\n" +
+				"20240101T000000Z\twork\tAlpha\t2024-01-01T00:00:00+00:00\t",
+			false,
+		},
+		{
+			"Format",
+			Get{Format: "{{.Title}}={{.Fields.status}}"},
+			"gamma=draft\nbeta=<no value>\nAlpha=<no value>",
+			false,
+		},
+		{"Format with unknown field", Get{Format: "{{.Nope}}"}, "", true},
+		{"Sort by title", Get{Sort: "title"}, "20240101T000000Z\n20240103T000000Z\n20240102T000000Z", false},
+		{"Sort by timestamp", Get{Sort: "timestamp"}, "20240101T000000Z\n20240102T000000Z\n20240103T000000Z", false},
+		{"Sort by uid", Get{Sort: "uid"}, "20240101T000000Z\n20240102T000000Z\n20240103T000000Z", false},
+		{"Sort by unknown key", Get{Sort: "size"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			tc.cmd.ConfigPath = configPath
+			tc.cmd.Query = []string{"notes"}
+
+			// WHEN
+			output, err := tc.cmd.Run()
+
+			// THEN
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedOutput, output)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestGetNotesByTagsSkipsMalformedNotes(t *testing.T) {
+	// GIVEN
+	configPath, repositories := createZettelkasten(t, "main")
+	book := notes.NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	book.Header.Tags = []string{"book"}
+	repositories["main"].Put(book)
+	other := notes.NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	repositories["main"].Put(other)
+	os.WriteFile(repositories["main"].GetNotePath("20240103T000000Z"), []byte("No header."), 0644)
+
+	// WHEN
+	output, err := Get{ConfigPath: configPath, Query: []string{"notes"}, TagQuery: "NOT book"}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "20240102T000000Z", output)
+}
+
+func TestGetNoteAsInFile(t *testing.T) {
+	// GIVEN
+	configPath, repositories := createZettelkasten(t, "main")
+	content := "\n---\n" +
+		"title: Dune\n" +
+		"timestamp: \"2024-01-01T00:00:00+00:00\"\n" +
+		"uid: 20240101T000000Z\n" +
+		"tags: []\n" +
+		"referred_from: []\n" +
+		"refers_to: []\n" +
+		"---\n" +
+		"\n\n" +
+		"Body.  \n"
+	os.WriteFile(repositories["main"].GetNotePath("20240101T000000Z"), []byte(content), 0644)
+
+	// WHEN
+	output, err := Get{ConfigPath: configPath, Query: []string{"note", "20240101T000000Z"}}.Run()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, content, output)
+}
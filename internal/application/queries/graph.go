@@ -0,0 +1,140 @@
+package queries
+
+import "encoding/json"
+import "encoding/xml"
+import "errors"
+import "fmt"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// Graph carries required params to export graph of links between notes.
+type Graph struct {
+	ZettelkastenDir string
+	// Format is one of: dot, graphml, json.
+	Format string
+}
+
+// Run prints graph of all notes and references between them, in chosen
+// format. Titles, tags and workspaces are attached to nodes.
+func (self Graph) Run() (string, error) {
+	render, ok := map[string]func(notes.Graph) (string, error){
+		"dot":     renderDot,
+		"graphml": renderGraphML,
+		"json":    renderGraphJSON,
+	}[self.Format]
+	if !ok {
+		return "", fmt.Errorf("Format '%s' is not supported (available: dot, graphml, json)", self.Format)
+	}
+
+	indexed, err := workspaces.GetIndexedWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot build graph of notes"))
+	}
+	return render(notes.BuildGraph(indexed))
+}
+
+func renderDot(graph notes.Graph) (string, error) {
+	quote := func(text string) string {
+		text = strings.ReplaceAll(text, `\`, `\\`)
+		text = strings.ReplaceAll(text, `"`, `\"`)
+		text = strings.ReplaceAll(text, "\n", `\n`)
+		return `"` + text + `"`
+	}
+
+	lines := []string{"digraph zettelkasten {"}
+	for _, node := range graph.Nodes {
+		label := node.Title
+		if label == "" {
+			label = node.Uid
+		}
+		lines = append(
+			lines,
+			fmt.Sprintf(
+				"  %s [label=%s, workspace=%s, tags=%s];",
+				quote(node.Uid), quote(label), quote(node.Workspace), quote(strings.Join(node.Tags, ",")),
+			),
+		)
+	}
+	for _, edge := range graph.Edges {
+		lines = append(lines, fmt.Sprintf("  %s -> %s;", quote(edge.Source), quote(edge.Target)))
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n"), nil
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+func renderGraphML(graph notes.Graph) (string, error) {
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "title", For: "node", AttrName: "title", AttrType: "string"},
+			{ID: "tags", For: "node", AttrName: "tags", AttrType: "string"},
+			{ID: "workspace", For: "node", AttrName: "workspace", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "zettelkasten", EdgeDefault: "directed"},
+	}
+	for _, node := range graph.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: node.Uid,
+			Data: []graphMLData{
+				{Key: "title", Value: node.Title},
+				{Key: "tags", Value: strings.Join(node.Tags, ",")},
+				{Key: "workspace", Value: node.Workspace},
+			},
+		})
+	}
+	for _, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: edge.Source, Target: edge.Target})
+	}
+
+	marshalled, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot marshall graph to GraphML"))
+	}
+	return xml.Header + string(marshalled), nil
+}
+
+func renderGraphJSON(graph notes.Graph) (string, error) {
+	marshalled, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot marshall graph to JSON"))
+	}
+	return string(marshalled), nil
+}
@@ -0,0 +1,101 @@
+package queries
+
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+
+func TestRenderGraph(t *testing.T) {
+	// GIVEN
+	graph := notes.Graph{
+		Nodes: []notes.GraphNode{
+			{Uid: "20240101T000000Z", Title: "Say \"hi\"\nto C:\\", Tags: []string{"a", "b"}, Workspace: "main"},
+			{Uid: "20240102T000000Z", Title: "", Tags: []string{}, Workspace: "work"},
+		},
+		Edges: []notes.GraphEdge{
+			{Source: "20240102T000000Z", Target: "20240101T000000Z"},
+		},
+	}
+
+	testCases := []struct {
+		testName string
+		render   func(notes.Graph) (string, error)
+		expected string
+	}{
+		{
+			"DOT",
+			renderDot,
+			"digraph zettelkasten {\n" +
+				`  "20240101T000000Z" [label="Say \"hi\"\nto C:\\", workspace="main", tags="a,b"];` + "\n" +
+				`  "20240102T000000Z" [label="20240102T000000Z", workspace="work", tags=""];` + "\n" +
+				`  "20240102T000000Z" -> "20240101T000000Z";` + "\n" +
+				"}",
+		},
+		{
+			"GraphML",
+			renderGraphML,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n" +
+				`  <key id="title" for="node" attr.name="title" attr.type="string"></key>` + "\n" +
+				`  <key id="tags" for="node" attr.name="tags" attr.type="string"></key>` + "\n" +
+				`  <key id="workspace" for="node" attr.name="workspace" attr.type="string"></key>` + "\n" +
+				`  <graph id="zettelkasten" edgedefault="directed">` + "\n" +
+				`    <node id="20240101T000000Z">` + "\n" +
+				`      <data key="title">Say &#34;hi&#34;&#xA;to C:\</data>` + "\n" +
+				`      <data key="tags">a,b</data>` + "\n" +
+				`      <data key="workspace">main</data>` + "\n" +
+				`    </node>` + "\n" +
+				`    <node id="20240102T000000Z">` + "\n" +
+				`      <data key="title"></data>` + "\n" +
+				`      <data key="tags"></data>` + "\n" +
+				`      <data key="workspace">work</data>` + "\n" +
+				`    </node>` + "\n" +
+				`    <edge source="20240102T000000Z" target="20240101T000000Z"></edge>` + "\n" +
+				`  </graph>` + "\n" +
+				`</graphml>`,
+		},
+		{
+			"JSON",
+			renderGraphJSON,
+			`{
+  "nodes": [
+    {
+      "uid": "20240101T000000Z",
+      "title": "Say \"hi\"\nto C:\\",
+      "tags": [
+        "a",
+        "b"
+      ],
+      "workspace": "main"
+    },
+    {
+      "uid": "20240102T000000Z",
+      "title": "",
+      "tags": [],
+      "workspace": "work"
+    }
+  ],
+  "edges": [
+    {
+      "source": "20240102T000000Z",
+      "target": "20240101T000000Z"
+    }
+  ]
+}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			actual, err := tc.render(graph)
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
@@ -0,0 +1,137 @@
+package queries
+
+import "errors"
+import "fmt"
+import "slices"
+import "strings"
+import "text/template"
+import "time"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+
+// longListingFormat is a template of columnar listing of notes: tab separated
+// UID, workspace, title, timestamp and comma separated tags.
+const longListingFormat = "{{.Uid}}\t{{.Workspace}}\t{{.Title}}\t{{.Timestamp}}\t{{join .Tags \",\"}}"
+
+// noteListing is a flat view of a listed note, exposed to user-supplied
+// templates, e.g. `{{.Uid}} {{.Title}}`.
+type noteListing struct {
+	Uid       string // revive:disable-line
+	Workspace string
+	Path      string
+	Title     string
+	Timestamp string
+	Tags      []string
+	// Fields are custom fields of the header, formatted as text, e.g.
+	// `{{.Fields.status}}`. Fields declared in config are always present.
+	Fields map[string]string
+}
+
+func newNoteListing(record headerRecord, schema notes.FieldSchema) noteListing {
+	fields := make(map[string]string)
+	for name := range schema {
+		fields[name] = ""
+	}
+	for name := range record.Header.Extra {
+		fields[name] = record.Header.FieldValue(name)
+	}
+	return noteListing{
+		Uid:       record.Header.Uid,
+		Workspace: record.Workspace,
+		Path:      record.Path,
+		Title:     record.Header.Title,
+		Timestamp: record.Header.Timestamp,
+		Tags:      record.Header.Tags,
+		Fields:    fields,
+	}
+}
+
+// parseListingTemplate compiles template printing a single note. Besides
+// builtins, `join` is available to format lists, e.g. `{{join .Tags " "}}`.
+// Unknown fields of noteListing fail when executed; absent custom fields, e.g.
+// `{{.Fields.status}}`, are printed as `<no value>` unless declared in config.
+func parseListingTemplate(format string) (*template.Template, error) {
+	tmpl, err := template.New("listing").Funcs(template.FuncMap{"join": strings.Join}).Parse(format)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("Invalid format '%s'", format))
+	}
+	return tmpl, nil
+}
+
+func executeListingTemplate(tmpl *template.Template, record headerRecord, schema notes.FieldSchema) (string, error) {
+	var rendered strings.Builder
+	err := tmpl.Execute(&rendered, newNoteListing(record, schema))
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("Cannot format note with UID '%s'", record.Header.Uid))
+	}
+	return rendered.String(), nil
+}
+
+// sortHeaderRecords orders records in place by given key: timestamp, title or
+// uid. Empty key keeps the order intact.
+func sortHeaderRecords(records []headerRecord, key string) error {
+	switch key {
+	case "":
+		return nil
+	case "uid":
+		slices.SortStableFunc(records, func(lhs, rhs headerRecord) int {
+			return strings.Compare(lhs.Header.Uid, rhs.Header.Uid)
+		})
+		return nil
+	case "title":
+		slices.SortStableFunc(records, func(lhs, rhs headerRecord) int {
+			return strings.Compare(strings.ToLower(lhs.Header.Title), strings.ToLower(rhs.Header.Title))
+		})
+		return nil
+	case "timestamp":
+		times := make(map[string]time.Time)
+		for _, record := range records {
+			when, err := record.Header.GetTime()
+			if err != nil {
+				return errors.Join(err, fmt.Errorf("Cannot sort note with UID '%s'", record.Header.Uid))
+			}
+			times[record.Header.Uid] = when
+		}
+		slices.SortStableFunc(records, func(lhs, rhs headerRecord) int {
+			return times[lhs.Header.Uid].Compare(times[rhs.Header.Uid])
+		})
+		return nil
+	}
+	return fmt.Errorf("Sort key '%s' is not supported (available: timestamp, title, uid)", key)
+}
+
+// fieldFilter selects notes by custom field of their headers, see
+// notes.Header.HasField.
+type fieldFilter struct {
+	Name  string
+	Value string
+}
+
+// parseFieldFilters reads filters given as `name=value`. Unless schema is
+// empty, only declared fields can be used.
+func parseFieldFilters(texts []string, schema notes.FieldSchema) ([]fieldFilter, error) {
+	filters := []fieldFilter{}
+	for _, text := range texts {
+		name, value, isAssignment := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !isAssignment || name == "" {
+			return []fieldFilter{}, fmt.Errorf("Field filter '%s' must look like name=value", text)
+		}
+		if _, isDeclared := schema[name]; len(schema) > 0 && !isDeclared {
+			return []fieldFilter{}, fmt.Errorf(
+				"Field '%s' is not declared in config (available: %s)", name, strings.Join(schema.Names(), ", "),
+			)
+		}
+		filters = append(filters, fieldFilter{Name: name, Value: strings.TrimSpace(value)})
+	}
+	return filters, nil
+}
+
+func matchesFieldFilters(header notes.Header, filters []fieldFilter) bool {
+	for _, filter := range filters {
+		if !header.HasField(filter.Name, filter.Value) {
+			return false
+		}
+	}
+	return true
+}
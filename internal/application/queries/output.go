@@ -0,0 +1,92 @@
+package queries
+
+import "encoding/json"
+import "errors"
+import "fmt"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+
+// OutputFormat decides how results of queries are printed.
+type OutputFormat string
+
+// Supported output formats. Text is meant for humans, the others for scripts
+// and editor integrations: JSON prints single document, NDJSON prints one
+// document per line.
+const (
+	TextOutput   OutputFormat = "text"
+	JSONOutput   OutputFormat = "json"
+	NDJSONOutput OutputFormat = "ndjson"
+)
+
+// ParseOutputFormat validates name of OutputFormat.
+func ParseOutputFormat(text string) (OutputFormat, error) {
+	switch OutputFormat(text) {
+	case TextOutput, JSONOutput, NDJSONOutput:
+		return OutputFormat(text), nil
+	}
+	return "", fmt.Errorf("Output format '%s' is not supported (available: text, json, ndjson)", text)
+}
+
+// IsStructured tells if results are serialised instead of printed as text.
+// Empty OutputFormat is treated as text.
+func (self OutputFormat) IsStructured() bool {
+	return self == JSONOutput || self == NDJSONOutput
+}
+
+// noteRecord is a serialisable Note together with its location.
+type noteRecord struct {
+	Workspace string `json:"workspace"`
+	Path      string `json:"path"`
+	notes.Note
+}
+
+// headerRecord is a serialisable Header of a listed note together with its
+// location.
+type headerRecord struct {
+	Workspace string       `json:"workspace"`
+	Path      string       `json:"path"`
+	Header    notes.Header `json:"header"`
+}
+
+// renderList prints lines if format is text, otherwise serialises records.
+func renderList[T any](format OutputFormat, lines []string, records []T) (string, error) {
+	switch format {
+	case JSONOutput:
+		return marshalJSON(records)
+	case NDJSONOutput:
+		marshalledLines := []string{}
+		for _, record := range records {
+			marshalled, err := json.Marshal(record)
+			if err != nil {
+				return "", errors.Join(err, errors.New("Cannot marshall result to JSON"))
+			}
+			marshalledLines = append(marshalledLines, string(marshalled))
+		}
+		return strings.Join(marshalledLines, "\n"), nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// renderSingle prints text if format is text, otherwise serialises record.
+func renderSingle[T any](format OutputFormat, text string, record T) (string, error) {
+	switch format {
+	case JSONOutput:
+		return marshalJSON(record)
+	case NDJSONOutput:
+		marshalled, err := json.Marshal(record)
+		if err != nil {
+			return "", errors.Join(err, errors.New("Cannot marshall result to JSON"))
+		}
+		return string(marshalled), nil
+	}
+	return text, nil
+}
+
+func marshalJSON(value any) (string, error) {
+	marshalled, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot marshall result to JSON"))
+	}
+	return string(marshalled), nil
+}
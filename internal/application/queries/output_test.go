@@ -0,0 +1,144 @@
+package queries
+
+import "path"
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+func TestParseOutputFormat(t *testing.T) {
+	testCases := []struct {
+		text        string
+		expected    OutputFormat
+		expectedErr bool
+	}{
+		{"text", TextOutput, false},
+		{"json", JSONOutput, false},
+		{"ndjson", NDJSONOutput, false},
+		{"yaml", "", true},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			actual, err := ParseOutputFormat(tc.text)
+
+			// THEN
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expected, actual)
+		}
+
+		t.Run(tc.text, testFunc)
+	}
+}
+
+func TestRenderList(t *testing.T) {
+	// GIVEN
+	records := []headerRecord{
+		{Workspace: "main", Path: "/zk/main/notes/20240101T000000Z.md", Header: notes.Header{Uid: "20240101T000000Z"}},
+		{Workspace: "work", Path: "/zk/work/notes/20240102T000000Z.md", Header: notes.Header{Title: "Two"}},
+	}
+	lines := []string{"20240101T000000Z", "20240102T000000Z"}
+
+	testCases := []struct {
+		testName string
+		format   OutputFormat
+		records  []headerRecord
+		expected string
+	}{
+		{"Text", TextOutput, records, "20240101T000000Z\n20240102T000000Z"},
+		{"Empty format", "", records, "20240101T000000Z\n20240102T000000Z"},
+		{
+			"JSON",
+			JSONOutput,
+			records[:1],
+			`[
+  {
+    "workspace": "main",
+    "path": "/zk/main/notes/20240101T000000Z.md",
+    "header": {
+      "title": "",
+      "timestamp": "",
+      "uid": "20240101T000000Z",
+      "tags": null,
+      "referred_from": null,
+      "refers_to": null
+    }
+  }
+]`,
+		},
+		{"JSON without results", JSONOutput, []headerRecord{}, "[]"},
+		{
+			"NDJSON",
+			NDJSONOutput,
+			records,
+			`{"workspace":"main","path":"/zk/main/notes/20240101T000000Z.md","header":` +
+				`{"title":"","timestamp":"","uid":"20240101T000000Z","tags":null,"referred_from":null,"refers_to":null}}` +
+				"\n" +
+				`{"workspace":"work","path":"/zk/work/notes/20240102T000000Z.md","header":` +
+				`{"title":"Two","timestamp":"","uid":"","tags":null,"referred_from":null,"refers_to":null}}`,
+		},
+		{"NDJSON without results", NDJSONOutput, []headerRecord{}, ""},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			actual, err := renderList(tc.format, lines, tc.records)
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestRenderSingle(t *testing.T) {
+	// GIVEN
+	record := tagRecord{Tag: "book", Count: 2}
+
+	testCases := []struct {
+		format   OutputFormat
+		expected string
+	}{
+		{TextOutput, "book\t2"},
+		{JSONOutput, "{\n  \"tag\": \"book\",\n  \"count\": 2\n}"},
+		{NDJSONOutput, `{"tag":"book","count":2}`},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			actual, err := renderSingle(tc.format, "book\t2", record)
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		}
+
+		t.Run(string(tc.format), testFunc)
+	}
+}
+
+func TestRenderWorkspace(t *testing.T) {
+	// GIVEN
+	zkdir := t.TempDir()
+	err := workspaces.CreateWorkspace(zkdir, "main")
+	assert.Nil(t, err)
+	foundWorkspaces, err := workspaces.GetWorkspaces(zkdir)
+	assert.Nil(t, err)
+
+	// WHEN
+	actual, err := renderList(NDJSONOutput, []string{}, foundWorkspaces)
+
+	// THEN
+	assert.Nil(t, err)
+	expected := `{"name":"main","path":"` + path.Join(zkdir, "main") +
+		`","notes_path":"` + path.Join(zkdir, "main", "notes") +
+		`","index_path":"` + path.Join(zkdir, "main", "index") + `"}`
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,66 @@
+package queries
+
+import "errors"
+import "fmt"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// Search carries required params to look for notes containing given words or
+// phrases.
+type Search struct {
+	ZettelkastenDir string
+	Query           string
+	CaseSensitive   bool
+	WorkspaceName   string
+	Output          OutputFormat
+}
+
+// searchRecord is a serialisable SearchResult together with workspace of the
+// found note.
+type searchRecord struct {
+	Workspace string `json:"workspace"`
+	notes.SearchResult
+}
+
+// Run prints matching notes, one per line, as tab separated UID, title and
+// snippet.
+func (self Search) Run() (string, error) {
+	query, err := notes.ParseSearchQuery(self.Query, self.CaseSensitive)
+	if err != nil {
+		return "", err
+	}
+
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+
+	isWorkspaceFound := false
+	lines := []string{}
+	records := []searchRecord{}
+	for _, ws := range foundWorkspaces {
+		if self.WorkspaceName != "" && ws.GetName() != self.WorkspaceName {
+			continue
+		}
+		isWorkspaceFound = true
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		index, err := notes.UpdateInvertedIndexFile(repository, ws.GetInvertedIndexPath())
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot index notes in workspace %s", ws.GetName()))
+		}
+		results, err := notes.SearchIndexedNotes(repository, &index, query)
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot search notes in workspace %s", ws.GetName()))
+		}
+		for _, result := range results {
+			lines = append(lines, fmt.Sprintf("%s\t%s\t%s", result.Uid, result.Title, result.Snippet))
+			records = append(records, searchRecord{Workspace: ws.GetName(), SearchResult: result})
+		}
+	}
+
+	if !isWorkspaceFound {
+		return "", fmt.Errorf("Workspace %s does not exist", self.WorkspaceName)
+	}
+	return renderList(self.Output, lines, records)
+}
@@ -0,0 +1,55 @@
+package queries
+
+import "errors"
+import "fmt"
+import "slices"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+import "github.com/radiand/zettelkasten/internal/workspaces"
+
+// Tags carries required params to list tags used in notes.
+type Tags struct {
+	ZettelkastenDir string
+	Output          OutputFormat
+}
+
+// tagRecord is a serialisable tag with number of notes labelled with it.
+type tagRecord struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// Run prints all tags, sorted, with number of notes labelled with each of
+// them across all workspaces.
+func (self Tags) Run() (string, error) {
+	foundWorkspaces, err := workspaces.GetWorkspaces(self.ZettelkastenDir)
+	if err != nil {
+		return "", fmt.Errorf("Could not find any workspaces in %s", self.ZettelkastenDir)
+	}
+
+	counts := make(map[string]int)
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		index, err := notes.UpdateInvertedIndexFile(repository, ws.GetInvertedIndexPath())
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("Cannot index notes in workspace %s", ws.GetName()))
+		}
+		for tag, uids := range index.Tags {
+			counts[tag] += len(uids)
+		}
+	}
+
+	tags := []string{}
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	lines := []string{}
+	records := []tagRecord{}
+	for _, tag := range tags {
+		lines = append(lines, fmt.Sprintf("%s\t%d", tag, counts[tag]))
+		records = append(records, tagRecord{Tag: tag, Count: counts[tag]})
+	}
+	return renderList(self.Output, lines, records)
+}
@@ -106,3 +106,18 @@ func (self Usage) Render(flagset *flag.FlagSet) []string {
 	lines = append(lines, argumentsRendered...)
 	return lines
 }
+
+// StringList is a flag.Value collecting all occurrences of repeatable flag,
+// e.g. `-tag a -tag b`.
+type StringList []string
+
+// String renders collected values, comma separated.
+func (self *StringList) String() string {
+	return strings.Join(*self, ",")
+}
+
+// Set appends next occurrence of the flag.
+func (self *StringList) Set(value string) error {
+	*self = append(*self, value)
+	return nil
+}
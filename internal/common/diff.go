@@ -0,0 +1,144 @@
+package common
+
+import "fmt"
+import "strings"
+
+// diffContext is the number of unchanged lines surrounding changes in
+// UnifiedDiff hunks.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff compares two texts line by line and renders the difference in
+// unified diff format, like `diff -u` does. Returns empty string if texts are
+// equal.
+func UnifiedDiff(fromName string, toName string, from string, to string) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// Line numbers (0-based) in both texts at the beginning of each op.
+	fromLine, toLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for idx, op := range ops {
+		fromLine[idx+1], toLine[idx+1] = fromLine[idx], toLine[idx]
+		if op.kind != '+' {
+			fromLine[idx+1]++
+		}
+		if op.kind != '-' {
+			toLine[idx+1]++
+		}
+	}
+
+	idx := 0
+	for idx < len(ops) {
+		if ops[idx].kind == ' ' {
+			idx++
+			continue
+		}
+		// Hunk spans from first change minus context up to the point where
+		// there are more than 2*context unchanged lines in a row.
+		start := max(0, idx-diffContext)
+		end := idx
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(run, end+diffContext)
+				break
+			}
+			end = run
+		}
+
+		fromCount := fromLine[end] - fromLine[start]
+		toCount := toLine[end] - toLine[start]
+		fmt.Fprintf(
+			&out, "@@ -%s +%s @@\n",
+			hunkRange(fromLine[start], fromCount), hunkRange(toLine[start], toCount),
+		)
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		idx = end
+	}
+	return out.String()
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines finds the shortest edit script turning one list of lines into
+// another, based on their longest common subsequence.
+func diffLines(from []string, to []string) []diffOp {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := []diffOp{}
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			ops = append(ops, diffOp{' ', from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', from[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		ops = append(ops, diffOp{'-', from[i]})
+	}
+	for ; j < len(to); j++ {
+		ops = append(ops, diffOp{'+', to[j]})
+	}
+	return ops
+}
@@ -0,0 +1,61 @@
+package common
+
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+func TestUnifiedDiff(t *testing.T) {
+	// GIVEN
+	from := "title = \"\"\n" +
+		"tags = []\n" +
+		"referred_from = []\n" +
+		"refers_to = []\n"
+	to := "title = \"\"\n" +
+		"tags = []\n" +
+		"referred_from = [\"20240101T000000Z\"]\n" +
+		"refers_to = []\n"
+
+	// WHEN
+	actual := UnifiedDiff("a/note.md", "b/note.md", from, to)
+
+	// THEN
+	expected := "--- a/note.md\n" +
+		"+++ b/note.md\n" +
+		"@@ -1,4 +1,4 @@\n" +
+		" title = \"\"\n" +
+		" tags = []\n" +
+		"-referred_from = []\n" +
+		"+referred_from = [\"20240101T000000Z\"]\n" +
+		" refers_to = []\n"
+	assert.Equal(t, expected, actual)
+}
+
+func TestUnifiedDiffSplitsDistantChanges(t *testing.T) {
+	// GIVEN
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	to := "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n"
+
+	// WHEN
+	actual := UnifiedDiff("from", "to", from, to)
+
+	// THEN
+	expected := "--- from\n" +
+		"+++ to\n" +
+		"@@ -1,4 +1,4 @@\n" +
+		"-a\n" +
+		"+A\n" +
+		" b\n" +
+		" c\n" +
+		" d\n" +
+		"@@ -7,4 +7,4 @@\n" +
+		" g\n" +
+		" h\n" +
+		" i\n" +
+		"-j\n" +
+		"+J\n"
+	assert.Equal(t, expected, actual)
+}
+
+func TestUnifiedDiffOfEqualTexts(t *testing.T) {
+	assert.Equal(t, "", UnifiedDiff("from", "to", "a\nb\n", "a\nb\n"))
+}
@@ -1,5 +1,8 @@
 package common
 
+import "fmt"
+import "strconv"
+import "strings"
 import "time"
 import "os"
 
@@ -16,3 +19,49 @@ func ModificationTime(path string) (time.Time, error) {
 	}
 	return fstat.ModTime(), nil
 }
+
+// ParseMoment reads point in time given as a date (2006-01-02), a date with
+// time (RFC3339) or a duration back from now, e.g. 90m, 36h, 7d or 2w. Dates
+// are interpreted in location of now. Returned flag tells if only a date was
+// given, so the moment stands for the whole day starting at it.
+func ParseMoment(text string, now time.Time) (time.Time, bool, error) {
+	if when, err := time.ParseInLocation(time.DateOnly, text, now.Location()); err == nil {
+		return when, true, nil
+	}
+	if when, err := time.Parse(time.RFC3339, text); err == nil {
+		return when, false, nil
+	}
+	ago, err := parseDuration(text)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("'%s' is neither a date (2006-01-02), time (RFC3339) nor duration (e.g. 36h, 7d, 2w)", text)
+	}
+	return now.Add(-ago), false, nil
+}
+
+// StartOfDay truncates time to midnight, in its location.
+func StartOfDay(when time.Time) time.Time {
+	year, month, day := when.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, when.Location())
+}
+
+// parseDuration extends time.ParseDuration with days (d) and weeks (w), which
+// must be whole numbers, e.g. 7d.
+func parseDuration(text string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		count, found := strings.CutSuffix(text, suffix)
+		if !found {
+			continue
+		}
+		number, err := strconv.Atoi(count)
+		if err != nil || number < 0 {
+			return 0, fmt.Errorf("Invalid duration '%s'", text)
+		}
+		return time.Duration(number) * unit, nil
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("Invalid duration '%s'", text)
+	}
+	return duration, nil
+}
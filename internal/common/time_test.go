@@ -0,0 +1,64 @@
+package common
+
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func TestParseMoment(t *testing.T) {
+	// GIVEN
+	now := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		text          string
+		expected      time.Time
+		expectedIsDay bool
+	}{
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"2024-03-01T08:00:00+01:00", time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC), false},
+		{"36h", time.Date(2024, 3, 14, 0, 30, 0, 0, time.UTC), false},
+		{"7d", time.Date(2024, 3, 8, 12, 30, 0, 0, time.UTC), false},
+		{"2w", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), false},
+	}
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			actual, isDay, err := ParseMoment(tc.text, now)
+
+			// THEN
+			assert.Nil(t, err)
+			assert.True(t, tc.expected.Equal(actual), "expected %v, got %v", tc.expected, actual)
+			assert.Equal(t, tc.expectedIsDay, isDay)
+		}
+
+		t.Run(tc.text, testFunc)
+	}
+}
+
+func TestParseMomentRejectsGarbage(t *testing.T) {
+	// GIVEN
+	now := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+	texts := []string{"", "yesterday", "-7d", "1.5d", "2024-13-01"}
+
+	for _, text := range texts {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			_, _, err := ParseMoment(text, now)
+
+			// THEN
+			assert.NotNil(t, err)
+		}
+
+		t.Run(text, testFunc)
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	// GIVEN
+	when := time.Date(2024, 3, 15, 12, 30, 15, 10, time.UTC)
+
+	// WHEN
+	actual := StartOfDay(when)
+
+	// THEN
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), actual)
+}
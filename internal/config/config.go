@@ -7,8 +7,41 @@ import "github.com/BurntSushi/toml"
 
 // Config represents global, application wide options.
 type Config struct {
-	ZettelkastenDir  string `toml:"zettelkasten_dir"`
-	DefaultWorkspace string `toml:"default_workspace"`
+	ZettelkastenDir  string `toml:"zettelkasten_dir" json:"zettelkasten_dir"`
+	DefaultWorkspace string `toml:"default_workspace" json:"default_workspace"`
+	// Indices are collection notes generated from tag queries, defined as
+	// `[[indices]]` tables.
+	Indices []IndexDefinition `toml:"indices,omitempty" json:"indices,omitempty"`
+	// Fields declare custom fields of note headers with their types, e.g.
+	// `status = "string"` in `[fields]` table.
+	Fields map[string]string `toml:"fields,omitempty" json:"fields,omitempty"`
+	// Workspaces hold options of particular workspaces, defined as
+	// `[workspaces.<name>]` tables.
+	Workspaces map[string]WorkspaceOptions `toml:"workspaces,omitempty" json:"workspaces,omitempty"`
+}
+
+// WorkspaceOptions are options of a single workspace.
+type WorkspaceOptions struct {
+	// HeaderFormat of new notes: fenced-toml (default), toml or yaml.
+	HeaderFormat string `toml:"header_format,omitempty" json:"header_format,omitempty"`
+}
+
+// IndexDefinition describes collection note listing notes with matching tags.
+type IndexDefinition struct {
+	// Name of the index file, without extension.
+	Name string `toml:"name" json:"name"`
+	// Title is a heading of the index. Name is used if empty.
+	Title string `toml:"title,omitempty" json:"title,omitempty"`
+	// Description is put in header of the index file.
+	Description string `toml:"description,omitempty" json:"description,omitempty"`
+	// Query selects notes by tags, e.g. `book AND NOT status:abandoned`.
+	Query string `toml:"query" json:"query"`
+}
+
+// GetHeaderFormat returns header format of new notes in given workspace, or
+// empty string if the default one is used.
+func (self *Config) GetHeaderFormat(workspaceName string) string {
+	return self.Workspaces[workspaceName].HeaderFormat
 }
 
 // NewConfig creates config with default values.
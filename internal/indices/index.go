@@ -0,0 +1,134 @@
+/*
+Package indices generates collection notes, i.e. markdown files listing notes
+selected by tag queries.
+*/
+package indices
+
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "regexp"
+import "slices"
+import "strings"
+
+import "github.com/radiand/zettelkasten/internal/config"
+import "github.com/radiand/zettelkasten/internal/notes"
+
+// Entry is a single note listed in Index.
+type Entry struct {
+	Uid   string // revive:disable-line
+	Title string
+}
+
+// Index is a collection of notes matching tag query, built from definition in
+// config.
+type Index struct {
+	Definition config.IndexDefinition
+	Entries    []Entry
+}
+
+// ValidateName checks if name of index can be safely used as a file name.
+func ValidateName(name string) error {
+	if !regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`).MatchString(name) {
+		return fmt.Errorf(
+			"Invalid index name '%s': use letters, digits, '-' and '_' only", name,
+		)
+	}
+	return nil
+}
+
+// BuildIndex selects indexed notes with tags matching query of the definition.
+// Entries are ordered by Uids, so building from the same notes always gives
+// same Index.
+func BuildIndex(index *notes.InvertedIndex, definition config.IndexDefinition) (Index, error) {
+	err := ValidateName(definition.Name)
+	if err != nil {
+		return Index{}, err
+	}
+	tagQuery, err := notes.ParseTagQuery(definition.Query)
+	if err != nil {
+		return Index{}, errors.Join(err, fmt.Errorf("Invalid query of index '%s'", definition.Name))
+	}
+
+	entries := []Entry{}
+	for uid, indexed := range index.Notes {
+		if tagQuery.Matches(indexed.Tags) {
+			entries = append(entries, Entry{Uid: uid, Title: indexed.Title})
+		}
+	}
+	slices.SortFunc(entries, func(lhs, rhs Entry) int {
+		return strings.Compare(lhs.Uid, rhs.Uid)
+	})
+	return Index{Definition: definition, Entries: entries}, nil
+}
+
+// Render marshalls Index to markdown index file. Every entry links to the
+// note, relative to index directory of the workspace.
+func (self *Index) Render(notesDirName string) (string, error) {
+	title := self.Definition.Title
+	if title == "" {
+		title = self.Definition.Name
+	}
+	lines := []string{
+		"# " + title,
+		"",
+		fmt.Sprintf("<!-- %s from tag query: %s. Do not edit. -->", generatedMarker, self.Definition.Query),
+		"",
+	}
+	for _, entry := range self.Entries {
+		entryTitle := entry.Title
+		if entryTitle == "" {
+			entryTitle = "(untitled)"
+		}
+		lines = append(
+			lines,
+			fmt.Sprintf("- %s [%s](../%s/%s.md)", entry.Uid, escapeLinkText(entryTitle), notesDirName, entry.Uid),
+		)
+	}
+	indexFile := IndexFile{
+		Header: IndexHeader{
+			Name:        self.Definition.Name,
+			Description: self.Definition.Description,
+			Query:       self.Definition.Query,
+			Generated:   true,
+		},
+		Body: strings.Join(lines, "\n"),
+	}
+	return indexFile.ToToml()
+}
+
+// escapeLinkText prepares title to be put between brackets of markdown link:
+// brackets and backslashes are escaped and line breaks become spaces.
+func escapeLinkText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		"[", `\[`,
+		"]", `\]`,
+		"\r\n", " ",
+		"\n", " ",
+		"\r", " ",
+	)
+	return replacer.Replace(text)
+}
+
+// WriteIfChanged saves content to path, unless file already has exactly that
+// content. Returns true if file was written.
+func WriteIfChanged(path string, content string) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && string(existing) == content {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, errors.Join(err, fmt.Errorf("Cannot read %s", path))
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return false, errors.Join(err, fmt.Errorf("Cannot create directory for %s", path))
+	}
+	err = os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return false, errors.Join(err, fmt.Errorf("Cannot write %s", path))
+	}
+	return true, nil
+}
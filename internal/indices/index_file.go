@@ -0,0 +1,181 @@
+package indices
+
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "slices"
+import "strings"
+
+import "github.com/BurntSushi/toml"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+
+// generatedMarker is put in body of generated index files, so they can be told
+// apart from the ones curated by hand.
+const generatedMarker = "Generated by `zettelkasten index build`"
+
+// ErrMissingIndexHeader signals that index file does not start with
+// ```toml``` header.
+var ErrMissingIndexHeader = errors.New("Index file has no header")
+
+// IndexHeader is a metadata put on top of index file. It is marshalled as a
+// toml block, like Header of a Note.
+type IndexHeader struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+	// Query, if not empty, makes every note with matching tags a member of
+	// the index, in addition to notes referenced in the body.
+	Query string `toml:"query"`
+	// Generated marks files written by `index build`, which must not be
+	// edited by hand.
+	Generated bool `toml:"generated,omitempty"`
+}
+
+// IndexFile is an index, either generated or curated by hand, as stored in
+// index directory of a workspace.
+type IndexFile struct {
+	Header IndexHeader
+	Body   string
+}
+
+// ToToml marshalls IndexFile.
+func (self *IndexFile) ToToml() (string, error) {
+	header, err := toml.Marshal(self.Header)
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot marshall index header"))
+	}
+	return fmt.Sprintf("```toml\n%s```\n\n%s\n", header, self.Body), nil
+}
+
+// IsGenerated tells if IndexFile was written by `index build`, as marked in
+// its header. Marker in the body is not enough, as curated files can mention
+// it too.
+func (self *IndexFile) IsGenerated() bool {
+	return self.Header.Generated
+}
+
+// IsGeneratedContent tells if content of index file was written by `index
+// build`, including files without header, generated by former versions and
+// recognised by the marker in the body. Files with malformed header are
+// treated as curated.
+func IsGeneratedContent(content string) bool {
+	indexFile, err := UnmarshallIndexFile(content)
+	if errors.Is(err, ErrMissingIndexHeader) {
+		return strings.Contains(content, generatedMarker)
+	}
+	if err != nil {
+		return false
+	}
+	return indexFile.IsGenerated()
+}
+
+// Validate checks if IndexFile is consistent with name of its file, given
+// without extension, and if its query is correct.
+func (self *IndexFile) Validate(fileName string) error {
+	err := ValidateName(self.Header.Name)
+	if err != nil {
+		return err
+	}
+	if self.Header.Name != fileName {
+		return fmt.Errorf("Index name '%s' does not match its file name '%s'", self.Header.Name, fileName)
+	}
+	if self.Header.Query != "" {
+		_, err = notes.ParseTagQuery(self.Header.Query)
+		if err != nil {
+			return errors.Join(err, fmt.Errorf("Invalid query of index '%s'", self.Header.Name))
+		}
+	}
+	return nil
+}
+
+// FindMembers returns sorted Uids of notes listed in IndexFile, i.e. referred
+// in its body or, if it has query, notes of the inverted index with matching
+// tags.
+func (self *IndexFile) FindMembers(index *notes.InvertedIndex) ([]string, error) {
+	members := notes.FindUids(self.Body)
+	if self.Header.Query != "" {
+		tagQuery, err := notes.ParseTagQuery(self.Header.Query)
+		if err != nil {
+			return []string{}, errors.Join(err, fmt.Errorf("Invalid query of index '%s'", self.Header.Name))
+		}
+		for uid, indexed := range index.Notes {
+			if tagQuery.Matches(indexed.Tags) {
+				members = append(members, uid)
+			}
+		}
+	}
+	slices.Sort(members)
+	members = slices.Compact(members)
+	if members == nil {
+		members = []string{}
+	}
+	return members, nil
+}
+
+// UnmarshallIndexFile loads IndexFile from string. Header must be TOML, either
+// ```toml``` fenced or +++ front matter. Unknown header fields are rejected,
+// so typos do not pass silently.
+func UnmarshallIndexFile(content string) (IndexFile, error) {
+	raw, err := notes.SplitNote(content)
+	if errors.Is(err, notes.ErrMissingHeader) {
+		return IndexFile{}, ErrMissingIndexHeader
+	}
+	if err != nil {
+		return IndexFile{}, errors.Join(err, errors.New("Cannot unmarshall index header"))
+	}
+
+	if name := raw.Codec.Name(); name != notes.FencedTomlFormat && name != notes.TomlFormat {
+		return IndexFile{}, fmt.Errorf("Index header must be TOML, not %s", name)
+	}
+	var header IndexHeader
+	metadata, err := toml.Decode(raw.Header, &header)
+	if err != nil {
+		return IndexFile{}, errors.Join(err, errors.New("Cannot unmarshall index header"))
+	}
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		return IndexFile{}, fmt.Errorf("Unknown field '%s' in index header", undecoded[0])
+	}
+	return IndexFile{Header: header, Body: strings.TrimSpace(raw.Body)}, nil
+}
+
+// ListIndexFiles returns sorted names, without extension, of index files in
+// given directory. Missing directory has no index files.
+func ListIndexFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return []string{}, errors.Join(err, fmt.Errorf("Cannot list index files in %s", dir))
+	}
+	names := []string{}
+	for _, entry := range entries {
+		name, isMarkdown := strings.CutSuffix(entry.Name(), ".md")
+		if entry.IsDir() || !isMarkdown || strings.HasPrefix(name, ".") {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// GetIndexFile reads and validates index file with given name from
+// directory.
+func GetIndexFile(dir string, name string) (IndexFile, error) {
+	path := filepath.Join(dir, name+".md")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return IndexFile{}, errors.Join(err, fmt.Errorf("Cannot read index file %s", path))
+	}
+	indexFile, err := UnmarshallIndexFile(string(content))
+	if err != nil {
+		return IndexFile{}, err
+	}
+	err = indexFile.Validate(name)
+	if err != nil {
+		return IndexFile{}, err
+	}
+	return indexFile, nil
+}
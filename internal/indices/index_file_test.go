@@ -0,0 +1,137 @@
+package indices
+
+import "os"
+import "path/filepath"
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+
+func TestCuratedIndexFile(t *testing.T) {
+	// GIVEN
+	content := "```toml\n" +
+		"name = \"ideas\"\n" +
+		"description = \"Ideas worth revisiting\"\n" +
+		"query = \"idea AND NOT status:done\"\n" +
+		"```\n" +
+		"\n" +
+		"# Ideas\n" +
+		"\n" +
+		"- 20240103T000000Z best one\n" +
+		"- 20240101T000000Z see also 20240103T000000Z\n"
+	index := notes.NewInvertedIndex()
+	index.Notes["20240102T000000Z"] = notes.IndexedNote{Tags: []string{"idea"}}
+	index.Notes["20240104T000000Z"] = notes.IndexedNote{Tags: []string{"idea", "status:done"}}
+
+	// WHEN
+	indexFile, err := UnmarshallIndexFile(content)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "Ideas worth revisiting", indexFile.Header.Description)
+	assert.Nil(t, indexFile.Validate("ideas"))
+	assert.False(t, indexFile.IsGenerated())
+	members, err := indexFile.FindMembers(&index)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"20240101T000000Z", "20240102T000000Z", "20240103T000000Z"}, members)
+}
+
+func TestInvalidIndexFiles(t *testing.T) {
+	testCases := []struct {
+		testName string
+		content  string
+		expected string
+	}{
+		{
+			testName: "missing header",
+			content:  "# Ideas\n\n- 20240101T000000Z\n",
+			expected: ErrMissingIndexHeader.Error(),
+		},
+		{
+			testName: "unknown field",
+			content:  "```toml\nname = \"ideas\"\nqeury = \"idea\"\n```\n",
+			expected: "Unknown field 'qeury'",
+		},
+		{
+			testName: "yaml header",
+			content:  "---\nname: ideas\n---\n",
+			expected: "Index header must be TOML, not yaml",
+		},
+		{
+			testName: "name other than file name",
+			content:  "```toml\nname = \"books\"\n```\n",
+			expected: "does not match its file name",
+		},
+		{
+			testName: "invalid query",
+			content:  "```toml\nname = \"ideas\"\nquery = \"(idea\"\n```\n",
+			expected: "Invalid query",
+		},
+	}
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			indexFile, err := UnmarshallIndexFile(tc.content)
+			if err == nil {
+				err = indexFile.Validate("ideas")
+			}
+
+			// THEN
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+		}
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestListIndexFiles(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	for _, name := range []string{"books.md", "ideas.md", ".inverted_index.json", ".hidden.md", "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte{}, 0644)
+	}
+
+	// WHEN
+	names, err := ListIndexFiles(dir)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"books", "ideas"}, names)
+
+	// WHEN
+	names, err = ListIndexFiles(filepath.Join(dir, "missing"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Empty(t, names)
+}
+
+func TestIsGeneratedContent(t *testing.T) {
+	// GIVEN
+	marker := "<!-- Generated by `zettelkasten index build` from tag query: book. -->\n"
+	testCases := []struct {
+		testName string
+		content  string
+		expected bool
+	}{
+		{"Generated header", "```toml\nname = \"books\"\ngenerated = true\n```\n", true},
+		{"Marker without header", "# books\n\n" + marker, true},
+		{"Curated header", "```toml\nname = \"books\"\n```\n", false},
+		{"Curated header mentioning marker", "```toml\nname = \"books\"\n```\n\n" + marker, false},
+		{"Malformed header mentioning marker", "```toml\nnmae = \"books\"\n```\n\n" + marker, false},
+		{"Neither header nor marker", "# books\n", false},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			actual := IsGeneratedContent(tc.content)
+
+			// THEN
+			assert.Equal(t, tc.expected, actual)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
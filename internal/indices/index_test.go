@@ -0,0 +1,122 @@
+package indices
+
+import "os"
+import "path/filepath"
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+import "github.com/radiand/zettelkasten/internal/config"
+import "github.com/radiand/zettelkasten/internal/notes"
+
+func TestBuildAndRenderIndex(t *testing.T) {
+	// GIVEN
+	index := notes.NewInvertedIndex()
+	index.Notes["20240102T000000Z"] = notes.IndexedNote{Title: "Dune", Tags: []string{"book", "genre:scifi"}}
+	index.Notes["20240101T000000Z"] = notes.IndexedNote{Title: "", Tags: []string{"book"}}
+	index.Notes["20240103T000000Z"] = notes.IndexedNote{Title: "Paris", Tags: []string{"travel"}}
+
+	// WHEN
+	definition := config.IndexDefinition{Name: "books", Title: "Books", Description: "Read", Query: "book"}
+	built, err := BuildIndex(&index, definition)
+	assert.Nil(t, err)
+	rendered, err := built.Render("notes")
+
+	// THEN
+	assert.Nil(t, err)
+	expected := "```toml\n" +
+		"name = \"books\"\n" +
+		"description = \"Read\"\n" +
+		"query = \"book\"\n" +
+		"generated = true\n" +
+		"```\n" +
+		"\n" +
+		"# Books\n" +
+		"\n" +
+		"<!-- Generated by `zettelkasten index build` from tag query: book. Do not edit. -->\n" +
+		"\n" +
+		"- 20240101T000000Z [(untitled)](../notes/20240101T000000Z.md)\n" +
+		"- 20240102T000000Z [Dune](../notes/20240102T000000Z.md)\n"
+	assert.Equal(t, expected, rendered)
+
+	// WHEN
+	parsed, err := UnmarshallIndexFile(rendered)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Nil(t, parsed.Validate("books"))
+	assert.True(t, parsed.IsGenerated())
+	members, err := parsed.FindMembers(&index)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"20240101T000000Z", "20240102T000000Z"}, members)
+}
+
+func TestRenderIndexEscapesTitles(t *testing.T) {
+	testCases := []struct {
+		testName string
+		title    string
+		expected string
+	}{
+		{testName: "brackets", title: "[Draft] Dune", expected: `\[Draft\] Dune`},
+		{testName: "backslash", title: `C:\notes`, expected: `C:\\notes`},
+		{testName: "line breaks", title: "Dune\r\nMessiah\nChildren", expected: "Dune Messiah Children"},
+	}
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// GIVEN
+			index := notes.NewInvertedIndex()
+			index.Notes["20240101T000000Z"] = notes.IndexedNote{Title: tc.title, Tags: []string{"book"}}
+			built, err := BuildIndex(&index, config.IndexDefinition{Name: "books", Query: "book"})
+			assert.Nil(t, err)
+
+			// WHEN
+			rendered, err := built.Render("notes")
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Contains(t, rendered, "- 20240101T000000Z ["+tc.expected+"](../notes/20240101T000000Z.md)\n")
+		}
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestBuildIndexRejectsInvalidDefinitions(t *testing.T) {
+	testCases := []struct {
+		testName   string
+		definition config.IndexDefinition
+	}{
+		{testName: "name leaving index directory", definition: config.IndexDefinition{Name: "../books", Query: "book"}},
+		{testName: "invalid query", definition: config.IndexDefinition{Name: "books", Query: "book AND"}},
+	}
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// GIVEN
+			index := notes.NewInvertedIndex()
+
+			// WHEN
+			_, err := BuildIndex(&index, tc.definition)
+
+			// THEN
+			assert.NotNil(t, err)
+		}
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestWriteIfChanged(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "index", "books.md")
+
+	// WHEN
+	firstWritten, firstErr := WriteIfChanged(path, "# Books\n")
+	secondWritten, secondErr := WriteIfChanged(path, "# Books\n")
+	thirdWritten, thirdErr := WriteIfChanged(path, "# Books!\n")
+
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Nil(t, thirdErr)
+	assert.Equal(t, []bool{true, false, true}, []bool{firstWritten, secondWritten, thirdWritten})
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "# Books!\n", string(content))
+}
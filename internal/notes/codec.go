@@ -0,0 +1,284 @@
+package notes
+
+import "bytes"
+import "errors"
+import "fmt"
+import "regexp"
+import "slices"
+import "strconv"
+import "strings"
+import "time"
+
+import "github.com/BurntSushi/toml"
+import "gopkg.in/yaml.v3"
+
+// Names of supported HeaderCodecs, as used in config.
+const (
+	// FencedTomlFormat is a ```toml``` fenced block, the default.
+	FencedTomlFormat = "fenced-toml"
+	// TomlFormat is TOML front matter, fenced with `+++`, e.g. for Hugo.
+	TomlFormat = "toml"
+	// YamlFormat is YAML front matter, fenced with `---`, e.g. for Obsidian
+	// or Jekyll.
+	YamlFormat = "yaml"
+)
+
+// HeaderCodec marshalls Header in one of formats put on top of markdown
+// files. Reading Notes detects codec by the opening fence, so workspaces can
+// mix formats; writing uses the codec of a file or of a repository.
+type HeaderCodec interface {
+	// Name identifies codec in config, e.g. YamlFormat.
+	Name() string
+	// Opening is a line starting the header, without line ending.
+	Opening() string
+	// Closing is a line ending the header, without line ending.
+	Closing() string
+	// Marshal formats Header as lines put between fences.
+	Marshal(header Header) (string, error)
+	// Unmarshal reads Header from lines put between fences. Fields unknown
+	// to Header are kept in Header.Extra. Errors are *ParseError with line
+	// counted from the first line after the opening fence.
+	Unmarshal(text string) (Header, error)
+}
+
+// headerCodecs are all supported HeaderCodecs, the default one first.
+var headerCodecs = []HeaderCodec{
+	tomlCodec{name: FencedTomlFormat, opening: "```toml", closing: "```"},
+	tomlCodec{name: TomlFormat, opening: "+++", closing: "+++"},
+	yamlCodec{},
+}
+
+// DefaultHeaderCodec returns codec of ```toml``` fenced block.
+func DefaultHeaderCodec() HeaderCodec {
+	return headerCodecs[0]
+}
+
+// GetHeaderCodec finds HeaderCodec by name. Empty name gives the default one.
+func GetHeaderCodec(name string) (HeaderCodec, error) {
+	if name == "" {
+		return DefaultHeaderCodec(), nil
+	}
+	names := []string{}
+	for _, codec := range headerCodecs {
+		if codec.Name() == name {
+			return codec, nil
+		}
+		names = append(names, codec.Name())
+	}
+	return nil, fmt.Errorf("Header format '%s' is not supported (available: %s)", name, strings.Join(names, ", "))
+}
+
+// detectHeaderCodec finds HeaderCodec whose opening fence is given line.
+func detectHeaderCodec(line string) HeaderCodec {
+	for _, codec := range headerCodecs {
+		if strings.TrimRight(line, " \t\r\n") == codec.Opening() {
+			return codec
+		}
+	}
+	return nil
+}
+
+type tomlCodec struct {
+	name    string
+	opening string
+	closing string
+}
+
+func (self tomlCodec) Name() string    { return self.name }
+func (self tomlCodec) Opening() string { return self.opening }
+func (self tomlCodec) Closing() string { return self.closing }
+
+func (self tomlCodec) Marshal(header Header) (string, error) {
+	return header.ToToml()
+}
+
+func (self tomlCodec) Unmarshal(text string) (Header, error) {
+	var header Header
+	metadata, err := toml.Decode(text, &header)
+	if err != nil {
+		line := 0
+		var tomlErr toml.ParseError
+		if errors.As(err, &tomlErr) {
+			line = tomlErr.Position.Line
+		}
+		return Header{}, &ParseError{Line: line, Err: err}
+	}
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		// Header was decoded already, so the same text is valid toml.
+		var fields map[string]any
+		_, _ = toml.Decode(text, &fields)
+		header.Extra = make(map[string]any)
+		for _, key := range undecoded {
+			header.Extra[key[0]] = fields[key[0]]
+		}
+	}
+	return header, nil
+}
+
+type yamlCodec struct{}
+
+func (self yamlCodec) Name() string    { return YamlFormat }
+func (self yamlCodec) Opening() string { return "---" }
+func (self yamlCodec) Closing() string { return "---" }
+
+func (self yamlCodec) Marshal(header Header) (string, error) {
+	marshalled, err := marshalYaml(header)
+	if err != nil {
+		return "", err
+	}
+	if len(header.Extra) == 0 {
+		return marshalled, nil
+	}
+	extra, err := marshalYaml(toYamlValue(header.Extra))
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot marshall extra fields of header"))
+	}
+	return marshalled + extra, nil
+}
+
+func (self yamlCodec) Unmarshal(text string) (Header, error) {
+	var header Header
+	err := yaml.Unmarshal([]byte(text), &header)
+	if err != nil {
+		return Header{}, &ParseError{Line: findYamlErrorLine(err), Err: err}
+	}
+	// Header was decoded already, so the same text is valid yaml.
+	var document yaml.Node
+	_ = yaml.Unmarshal([]byte(text), &document)
+	fields, _ := fromYamlNode(&document).(map[string]any)
+	for name, value := range fields {
+		if slices.Contains(builtinFields, name) {
+			continue
+		}
+		if header.Extra == nil {
+			header.Extra = make(map[string]any)
+		}
+		header.Extra[name] = value
+	}
+	return header, nil
+}
+
+func marshalYaml(value any) (string, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err := encoder.Encode(value)
+	if err != nil {
+		return "", err
+	}
+	err = encoder.Close()
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// tomlLocalDate is the location toml decodes local dates, e.g. `read =
+// 2024-01-01`, into. Toml marshals times in that very location as dates.
+var tomlLocalDate = func() *time.Location {
+	var probe map[string]any
+	_, _ = toml.Decode("date = 2000-01-01", &probe)
+	return probe["date"].(time.Time).Location()
+}()
+
+// fromYamlNode decodes yaml node like yaml.Unmarshal does, except that dates
+// without time, e.g. `read: 2024-01-01`, become local dates like in toml,
+// instead of timestamps at midnight, so they are written back unchanged.
+func fromYamlNode(node *yaml.Node) any {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return fromYamlNode(node.Content[0])
+	case yaml.AliasNode:
+		return fromYamlNode(node.Alias)
+	case yaml.MappingNode:
+		fields := make(map[string]any)
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			fields[node.Content[idx].Value] = fromYamlNode(node.Content[idx+1])
+		}
+		return fields
+	case yaml.SequenceNode:
+		items := []any{}
+		for _, item := range node.Content {
+			items = append(items, fromYamlNode(item))
+		}
+		return items
+	}
+	if node.ShortTag() == "!!timestamp" {
+		if date, err := time.Parse(time.DateOnly, node.Value); err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, tomlLocalDate)
+		}
+	}
+	var value any
+	_ = node.Decode(&value)
+	return value
+}
+
+// toTomlValue prepares value to be marshalled as toml. Toml decodes local
+// dates and times at the local offset, but marshals them as seen in UTC, so
+// they are moved to UTC keeping the wall clock, not to shift e.g. dates by a
+// day east of Greenwich.
+func toTomlValue(value any) any {
+	switch typed := value.(type) {
+	case time.Time:
+		switch typed.Location().String() {
+		case "date-local", "datetime-local", "time-local":
+			wallClock := time.Date(
+				typed.Year(), typed.Month(), typed.Day(),
+				typed.Hour(), typed.Minute(), typed.Second(), typed.Nanosecond(), time.UTC,
+			)
+			return wallClock.In(typed.Location())
+		}
+	case []any:
+		items := []any{}
+		for _, item := range typed {
+			items = append(items, toTomlValue(item))
+		}
+		return items
+	case map[string]any:
+		fields := make(map[string]any)
+		for name, field := range typed {
+			fields[name] = toTomlValue(field)
+		}
+		return fields
+	}
+	return value
+}
+
+// toYamlValue prepares value decoded from toml to be marshalled as yaml. Local
+// dates of toml, e.g. `read = 2024-01-01`, stay dates instead of becoming
+// timestamps at midnight.
+func toYamlValue(value any) any {
+	switch typed := value.(type) {
+	case time.Time:
+		if typed.Location().String() == "date-local" {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: typed.Format(time.DateOnly)}
+		}
+	case []any:
+		items := []any{}
+		for _, item := range typed {
+			items = append(items, toYamlValue(item))
+		}
+		return items
+	case map[string]any:
+		fields := make(map[string]any)
+		for name, field := range typed {
+			fields[name] = toYamlValue(field)
+		}
+		return fields
+	}
+	return value
+}
+
+// findYamlErrorLine reads line number from yaml error, e.g. `yaml: line 3:
+// mapping values are not allowed in this context`, or returns 0.
+func findYamlErrorLine(err error) int {
+	matched := regexp.MustCompile(`line (\d+)`).FindStringSubmatch(err.Error())
+	if matched == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(matched[1])
+	return line
+}
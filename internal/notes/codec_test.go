@@ -0,0 +1,170 @@
+package notes
+
+import "errors"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func TestHeaderCodecsRoundTrip(t *testing.T) {
+	for _, format := range []string{FencedTomlFormat, TomlFormat, YamlFormat} {
+		testFunc := func(t *testing.T) {
+			// GIVEN
+			codec, err := GetHeaderCodec(format)
+			assert.Nil(t, err)
+			note := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			note.Header.Title = "Use `go vet`: often"
+			note.Header.Tags = []string{"go", "tools"}
+			note.Header.RefersTo = []string{"20240202T000000Z"}
+			note.Header.Extra = map[string]any{"status": "draft"}
+			note.Body = "Body."
+
+			// WHEN
+			marshalled, err := note.Marshal(codec)
+			assert.Nil(t, err)
+			loaded, err := UnmarshallNote(marshalled)
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Equal(t, note, loaded)
+			raw, _ := SplitNote(marshalled)
+			assert.Equal(t, format, raw.Codec.Name())
+		}
+
+		t.Run(format, testFunc)
+	}
+}
+
+func TestMarshalYamlHeader(t *testing.T) {
+	// GIVEN
+	note := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note.Header.Tags = []string{"a", "b"}
+	note.Body = "Body."
+	codec, _ := GetHeaderCodec(YamlFormat)
+
+	// WHEN
+	marshalled, err := note.Marshal(codec)
+
+	// THEN
+	assert.Nil(t, err)
+	expected := "---\n" +
+		"title: \"\"\n" +
+		"timestamp: \"2024-01-01T00:00:00+00:00\"\n" +
+		"uid: 20240101T000000Z\n" +
+		"tags: [a, b]\n" +
+		"referred_from: []\n" +
+		"refers_to: []\n" +
+		"---\n" +
+		"\n" +
+		"Body.\n"
+	assert.Equal(t, expected, marshalled)
+}
+
+func TestLoadMalformedYamlNote(t *testing.T) {
+	// WHEN
+	_, err := UnmarshallNote("---\ntitle: x\nfoo: bar: baz\n---\n")
+
+	// THEN
+	assert.True(t, errors.Is(err, ErrInvalidHeader))
+	var parseErr *ParseError
+	assert.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, 3, parseErr.Line)
+}
+
+func TestConvertHeader(t *testing.T) {
+	// GIVEN
+	content := "\n```toml\r\n" +
+		"title = \"Dune\"\r\n" +
+		"uid = \"20240101T000000Z\"\r\n" +
+		"read = 2024-01-01\r\n" +
+		"```\r\n" +
+		"\r\n\r\nBody.  \r\n"
+	yamlCodec, _ := GetHeaderCodec(YamlFormat)
+
+	// WHEN
+	converted, isConverted, err := ConvertHeader(content, yamlCodec)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.True(t, isConverted)
+	expected := "---\r\n" +
+		"title: Dune\r\n" +
+		"timestamp: \"\"\r\n" +
+		"uid: 20240101T000000Z\r\n" +
+		"tags: []\r\n" +
+		"referred_from: []\r\n" +
+		"refers_to: []\r\n" +
+		"read: 2024-01-01\r\n" +
+		"---\r\n" +
+		"\r\n\r\nBody.  \r\n"
+	assert.Equal(t, expected, converted)
+
+	// WHEN
+	again, isConvertedAgain, err := ConvertHeader(converted, yamlCodec)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.False(t, isConvertedAgain)
+	assert.Equal(t, converted, again)
+}
+
+func TestGetUnsupportedHeaderCodec(t *testing.T) {
+	// WHEN
+	codec, err := GetHeaderCodec("")
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, FencedTomlFormat, codec.Name())
+
+	// WHEN
+	_, err = GetHeaderCodec("xml")
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, "Header format 'xml' is not supported (available: fenced-toml, toml, yaml)", err.Error())
+}
+
+func TestYamlDatesRoundTrip(t *testing.T) {
+	// GIVEN
+	content := "---\n" +
+		"title: Dune\n" +
+		"timestamp: \"2024-01-01T00:00:00+00:00\"\n" +
+		"uid: 20240101T000000Z\n" +
+		"tags: []\n" +
+		"referred_from: []\n" +
+		"refers_to: []\n" +
+		"date: 2024-01-01\n" +
+		"read:\n" +
+		"  - 2024-02-01\n" +
+		"  - 2024-03-01\n" +
+		"updated: 2024-04-01T10:00:00Z\n" +
+		"---\n" +
+		"\n" +
+		"Body.\n"
+	tomlCodec, _ := GetHeaderCodec(FencedTomlFormat)
+
+	// WHEN
+	loaded, err := UnmarshallNote(content)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-01-01", loaded.Header.FieldValue("date"))
+	assert.True(t, loaded.Header.HasField("read", "2024-03-01"))
+
+	// WHEN
+	spliced, isSpliced, err := SpliceHeader(content, loaded)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.True(t, isSpliced)
+	assert.Equal(t, content, spliced)
+
+	// WHEN
+	converted, _, err := ConvertHeader(content, tomlCodec)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Contains(t, converted, "date = 2024-01-01\n")
+	assert.Contains(t, converted, "read = [2024-02-01, 2024-03-01]\n")
+	assert.Contains(t, converted, "updated = 2024-04-01T10:00:00Z\n")
+}
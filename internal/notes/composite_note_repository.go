@@ -0,0 +1,103 @@
+package notes
+
+import "errors"
+import "fmt"
+import "slices"
+
+// CompositeNoteRepository is an implementation of INoteRepository interface
+// joining many named repositories, e.g. of all workspaces, so they can be
+// treated as one. The same Uid must not exist in many of them, as it would be
+// ambiguous which Note it refers to; List reports such Uids as an error.
+type CompositeNoteRepository struct {
+	names        []string
+	repositories map[string]INoteRepository
+	owners       map[string]string
+}
+
+// Add attaches repository under given name.
+func (self *CompositeNoteRepository) Add(name string, repository INoteRepository) {
+	self.names = append(self.names, name)
+	self.repositories[name] = repository
+	self.owners = nil
+}
+
+// Get obtains Note from repository which contains it.
+func (self *CompositeNoteRepository) Get(uid string) (Note, error) {
+	owner, err := self.Locate(uid)
+	if err != nil {
+		return Note{}, err
+	}
+	return self.repositories[owner].Get(uid)
+}
+
+// Put saves Note in repository which already contains it. New Notes cannot be
+// saved, as it is unknown where they belong; use underlying repository
+// directly.
+func (self *CompositeNoteRepository) Put(note Note) (string, error) {
+	owner, err := self.Locate(note.Header.Uid)
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot save new note in composite repository"))
+	}
+	return self.repositories[owner].Put(note)
+}
+
+// List obtains array of Uids of Notes saved in all repositories. Listing is
+// cached for Locate. Uids existing in more than one repository are returned
+// as an error.
+func (self *CompositeNoteRepository) List() ([]string, error) {
+	owners := make(map[string]string)
+	uids := []string{}
+	duplicates := []error{}
+	for _, name := range self.names {
+		listed, err := self.repositories[name].List()
+		if err != nil {
+			return []string{}, errors.Join(err, fmt.Errorf("Cannot list notes of '%s'", name))
+		}
+		for _, uid := range listed {
+			if owner, isOwned := owners[uid]; isOwned {
+				duplicates = append(
+					duplicates,
+					fmt.Errorf("Note with UID '%s' exists in both '%s' and '%s'", uid, owner, name),
+				)
+				continue
+			}
+			owners[uid] = name
+			uids = append(uids, uid)
+		}
+	}
+	if len(duplicates) > 0 {
+		duplicates = append(duplicates, errors.New("Run doctor to find all duplicated UIDs"))
+		return []string{}, errors.Join(duplicates...)
+	}
+	self.owners = owners
+	return uids, nil
+}
+
+// Locate returns name of repository containing Note with given Uid. It relies
+// on the last listing, so Notes created afterwards are found only once List
+// is called again.
+func (self *CompositeNoteRepository) Locate(uid string) (string, error) {
+	if self.owners == nil {
+		_, err := self.List()
+		if err != nil {
+			return "", err
+		}
+	}
+	if owner, ok := self.owners[uid]; ok {
+		return owner, nil
+	}
+	return "", fmt.Errorf("Note with UID '%s' does not exist", uid)
+}
+
+// Names returns names of joined repositories, in order they were added.
+func (self *CompositeNoteRepository) Names() []string {
+	return slices.Clone(self.names)
+}
+
+// NewCompositeNoteRepository creates new, empty instance of the repository.
+func NewCompositeNoteRepository() *CompositeNoteRepository {
+	return &CompositeNoteRepository{
+		names:        []string{},
+		repositories: make(map[string]INoteRepository),
+	}
+}
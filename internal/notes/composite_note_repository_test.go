@@ -0,0 +1,100 @@
+package notes
+
+import "fmt"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func TestCompositeRepository(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	mainRepo := NewInMemoryNoteRepository()
+	mainRepo.Put(note1)
+	workRepo := NewInMemoryNoteRepository()
+	workRepo.Put(note2)
+
+	repository := NewCompositeNoteRepository()
+	repository.Add("main", mainRepo)
+	repository.Add("work", workRepo)
+
+	// WHEN
+	uids, err := repository.List()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{note1.Header.Uid, note2.Header.Uid}, uids)
+
+	// WHEN
+	owner, err := repository.Locate(note2.Header.Uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "work", owner)
+
+	// WHEN
+	note2.Header.Title = "Modified"
+	_, err = repository.Put(note2)
+	saved, _ := workRepo.Get(note2.Header.Uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "Modified", saved.Header.Title)
+
+	// WHEN
+	_, err = repository.Put(NewNote(time.Date(1993, 3, 3, 3, 3, 3, 0, time.UTC)))
+
+	// THEN
+	assert.NotNil(t, err)
+}
+
+func TestCompositeRepositoryWithDuplicatedUid(t *testing.T) {
+	// GIVEN
+	note := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	mainRepo := NewInMemoryNoteRepository()
+	mainRepo.Put(note)
+	workRepo := NewInMemoryNoteRepository()
+	workRepo.Put(note)
+
+	repository := NewCompositeNoteRepository()
+	repository.Add("main", mainRepo)
+	repository.Add("work", workRepo)
+
+	// WHEN
+	_, listErr := repository.List()
+	_, locateErr := repository.Locate(note.Header.Uid)
+
+	// THEN
+	assert.NotNil(t, listErr)
+	assert.Contains(t, fmt.Sprint(listErr), "Note with UID '19910101T010101Z' exists in both 'main' and 'work'")
+	assert.Contains(t, fmt.Sprint(listErr), "Run doctor")
+	assert.NotNil(t, locateErr)
+}
+
+// TestLinkNotesAcrossRepositories verifies if references between notes kept
+// in different repositories are reflected in both of them.
+func TestLinkNotesAcrossRepositories(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Body = fmt.Sprintf("Refers to [[%s]]", note1.Header.Uid)
+	mainRepo := NewInMemoryNoteRepository()
+	mainRepo.Put(note1)
+	workRepo := NewInMemoryNoteRepository()
+	workRepo.Put(note2)
+
+	repository := NewCompositeNoteRepository()
+	repository.Add("main", mainRepo)
+	repository.Add("work", workRepo)
+
+	// WHEN
+	_, err := LinkNotes(repository, nil)
+	note1, _ = mainRepo.Get(note1.Header.Uid)
+	note2, _ = workRepo.Get(note2.Header.Uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []string{note2.Header.Uid}, note1.Header.ReferredFrom)
+	assert.Equal(t, []string{note1.Header.Uid}, note2.Header.RefersTo)
+}
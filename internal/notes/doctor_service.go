@@ -0,0 +1,82 @@
+package notes
+
+import "fmt"
+import "slices"
+import "time"
+
+// Severity tells how serious a problem found by DiagnoseNote is.
+type Severity int
+
+const (
+	// SeverityWarning marks problems which do not break any command.
+	SeverityWarning Severity = iota
+	// SeverityError marks problems which make Note misbehave, e.g. be
+	// unreachable by its Uid.
+	SeverityError
+)
+
+// Diagnosis is a problem found in Note.
+type Diagnosis struct {
+	Severity Severity
+	Problem  string
+	// Fixable tells if problem is solved by FixNote without guessing.
+	Fixable bool
+}
+
+// DiagnoseNote checks Note loaded from file named after uid: whether its
+// Header has the same Uid, a Timestamp which can be parsed and whether it is
+// arranged, see Header.Arrange.
+func DiagnoseNote(uid string, note Note) []Diagnosis {
+	diagnoses := []Diagnosis{}
+	if note.Header.Uid != uid {
+		diagnoses = append(diagnoses, Diagnosis{
+			Severity: SeverityError,
+			Problem:  fmt.Sprintf("Header UID '%s' does not match file name", note.Header.Uid),
+			Fixable:  true,
+		})
+	}
+	if _, err := time.Parse(time.RFC3339, note.Header.Timestamp); err != nil {
+		_, uidErr := parseUidTime(uid)
+		diagnoses = append(diagnoses, Diagnosis{
+			Severity: SeverityError,
+			Problem:  fmt.Sprintf("Timestamp '%s' is not RFC3339", note.Header.Timestamp),
+			Fixable:  uidErr == nil,
+		})
+	}
+	if !isArranged(note.Header) {
+		diagnoses = append(diagnoses, Diagnosis{
+			Severity: SeverityWarning,
+			Problem:  "Tags or references are not lowercase and sorted",
+			Fixable:  true,
+		})
+	}
+	return diagnoses
+}
+
+// FixNote solves fixable problems reported by DiagnoseNote: Uid is taken from
+// file name, malformed Timestamp is recreated from Uid and Header is
+// arranged.
+func FixNote(uid string, note Note) Note {
+	note.Header.Uid = uid
+	if _, err := time.Parse(time.RFC3339, note.Header.Timestamp); err != nil {
+		if when, uidErr := parseUidTime(uid); uidErr == nil {
+			note.Header.Timestamp = NewHeader(when).Timestamp
+		}
+	}
+	note.Header.Arrange()
+	return note
+}
+
+func isArranged(header Header) bool {
+	arranged := header
+	arranged.Tags = slices.Clone(header.Tags)
+	arranged.ReferredFrom = slices.Clone(header.ReferredFrom)
+	arranged.RefersTo = slices.Clone(header.RefersTo)
+	arranged.IndexedIn = slices.Clone(header.IndexedIn)
+	arranged.Arrange()
+	return arranged.Equal(header)
+}
+
+func parseUidTime(uid string) (time.Time, error) {
+	return time.Parse("20060102T150405Z", uid)
+}
@@ -0,0 +1,47 @@
+package notes
+
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func TestDiagnoseHealthyNote(t *testing.T) {
+	// GIVEN
+	note := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note.Header.Tags = []string{"a", "b"}
+
+	// WHEN
+	diagnoses := DiagnoseNote("20240101T000000Z", note)
+
+	// THEN
+	assert.Empty(t, diagnoses)
+}
+
+func TestDiagnoseAndFixNote(t *testing.T) {
+	// GIVEN
+	note := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note.Header.Timestamp = "yesterday"
+	note.Header.Tags = []string{"B", "a"}
+
+	// WHEN
+	diagnoses := DiagnoseNote("20240202T000000Z", note)
+
+	// THEN
+	assert.Len(t, diagnoses, 3)
+	assert.Equal(t, SeverityError, diagnoses[0].Severity)
+	assert.Contains(t, diagnoses[0].Problem, "'20240101T000000Z'")
+	assert.Equal(t, SeverityError, diagnoses[1].Severity)
+	assert.Equal(t, SeverityWarning, diagnoses[2].Severity)
+	for _, diagnosis := range diagnoses {
+		assert.True(t, diagnosis.Fixable)
+	}
+
+	// WHEN
+	fixed := FixNote("20240202T000000Z", note)
+
+	// THEN
+	assert.Equal(t, "20240202T000000Z", fixed.Header.Uid)
+	assert.Equal(t, "2024-02-02T00:00:00+00:00", fixed.Header.Timestamp)
+	assert.Equal(t, []string{"a", "b"}, fixed.Header.Tags)
+	assert.Empty(t, DiagnoseNote("20240202T000000Z", fixed))
+}
@@ -0,0 +1,139 @@
+package notes
+
+import "fmt"
+import "slices"
+import "strings"
+import "time"
+
+// FieldTypes are names of types custom header fields can be declared with.
+var FieldTypes = []string{"string", "integer", "float", "boolean", "datetime", "array"}
+
+// FieldSchema declares custom fields of Headers, mapping their names to
+// FieldTypes, e.g. `status = "string"`. Empty schema allows any fields.
+type FieldSchema map[string]string
+
+// Validate checks if declared fields have supported types and do not shadow
+// fields of Header.
+func (self FieldSchema) Validate() error {
+	for _, name := range self.Names() {
+		if slices.Contains(builtinFields, name) {
+			return fmt.Errorf("Field '%s' is a part of every header and cannot be declared", name)
+		}
+		if !slices.Contains(FieldTypes, self[name]) {
+			return fmt.Errorf(
+				"Type '%s' of field '%s' is not supported (available: %s)",
+				self[name], name, strings.Join(FieldTypes, ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// Names returns sorted names of declared fields.
+func (self FieldSchema) Names() []string {
+	names := []string{}
+	for name := range self {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Diagnose reports Extra fields of Header which have other type than
+// declared or, unless schema is empty, are not declared at all, e.g. due to a
+// typo.
+func (self FieldSchema) Diagnose(header Header) []Diagnosis {
+	if len(self) == 0 {
+		return []Diagnosis{}
+	}
+	names := []string{}
+	for name := range header.Extra {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	diagnoses := []Diagnosis{}
+	for _, name := range names {
+		declared, isDeclared := self[name]
+		actual := fieldType(header.Extra[name])
+		if !isDeclared {
+			diagnoses = append(diagnoses, Diagnosis{
+				Severity: SeverityWarning,
+				Problem:  fmt.Sprintf("Field '%s' is not declared in config", name),
+			})
+		} else if actual != declared {
+			diagnoses = append(diagnoses, Diagnosis{
+				Severity: SeverityWarning,
+				Problem:  fmt.Sprintf("Field '%s' should be %s, not %s", name, declared, actual),
+			})
+		}
+	}
+	return diagnoses
+}
+
+// FieldValue returns Extra field of Header formatted as text, or empty string
+// if it is absent. Items of arrays are separated with commas.
+func (self *Header) FieldValue(name string) string {
+	value, ok := self.Extra[name]
+	if !ok {
+		return ""
+	}
+	if items, isArray := value.([]any); isArray {
+		formatted := []string{}
+		for _, item := range items {
+			formatted = append(formatted, formatFieldValue(item))
+		}
+		return strings.Join(formatted, ",")
+	}
+	return formatFieldValue(value)
+}
+
+// HasField tells if Extra field of Header equals value, or, if the field is
+// an array, contains it. Values are compared as formatted by FieldValue.
+func (self *Header) HasField(name string, value string) bool {
+	field, ok := self.Extra[name]
+	if !ok {
+		return false
+	}
+	if items, isArray := field.([]any); isArray {
+		return slices.ContainsFunc(items, func(item any) bool { return formatFieldValue(item) == value })
+	}
+	return formatFieldValue(field) == value
+}
+
+func formatFieldValue(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case time.Time:
+		// Local dates and times of toml are decoded into special locations.
+		switch typed.Location().String() {
+		case "date-local":
+			return typed.Format(time.DateOnly)
+		case "time-local":
+			return typed.Format(time.TimeOnly)
+		case "datetime-local":
+			return typed.Format("2006-01-02T15:04:05")
+		}
+		return typed.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+func fieldType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case int, int64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "datetime"
+	case []any:
+		return "array"
+	}
+	return "table"
+}
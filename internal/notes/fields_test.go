@@ -0,0 +1,51 @@
+package notes
+
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+func TestValidateFieldSchema(t *testing.T) {
+	// GIVEN
+	testCases := []struct {
+		testName string
+		schema   FieldSchema
+		isValid  bool
+	}{
+		{"Empty schema", FieldSchema{}, true},
+		{"Supported types", FieldSchema{"status": "string", "rating": "integer"}, true},
+		{"Unsupported type", FieldSchema{"status": "text"}, false},
+		{"Builtin field", FieldSchema{"tags": "array"}, false},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			err := tc.schema.Validate()
+
+			// THEN
+			if tc.isValid {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestDiagnoseFields(t *testing.T) {
+	// GIVEN
+	header := Header{Extra: map[string]any{"status": "draft", "rating": "5", "sorce": "book"}}
+	schema := FieldSchema{"status": "string", "rating": "integer", "source": "string"}
+
+	// WHEN
+	diagnoses := schema.Diagnose(header)
+
+	// THEN
+	assert.Equal(t, []Diagnosis{
+		{Severity: SeverityWarning, Problem: "Field 'rating' should be integer, not string"},
+		{Severity: SeverityWarning, Problem: "Field 'sorce' is not declared in config"},
+	}, diagnoses)
+	assert.Empty(t, FieldSchema{}.Diagnose(header))
+}
@@ -4,32 +4,103 @@ import "errors"
 import "os"
 import "path/filepath"
 import "strings"
+import "time"
+
+// ErrNoteExists signals that Note with the same Uid is already saved.
+var ErrNoteExists = errors.New("Note with this UID already exists")
 
 // FilesystemNoteRepository provides Notes saved on disk.
 type FilesystemNoteRepository struct {
 	RootDir string
+	// Codec is the format of headers of new Notes. Existing Notes keep their
+	// format. The default one is used if nil.
+	Codec HeaderCodec
 }
 
-// Get obtains Note from disk.
+// Get obtains Note from disk. Malformed Note is reported as *ParseError with
+// path to its file.
 func (self *FilesystemNoteRepository) Get(uid string) (Note, error) {
-	content, err := os.ReadFile(self.GetNotePath(uid))
+	content, err := self.GetRaw(uid)
 	if err != nil {
 		return Note{}, err
 	}
-	return UnmarshallNote(string(content))
+	nt, err := UnmarshallNote(content)
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		parseErr.Path = self.GetNotePath(uid)
+	}
+	return nt, err
+}
+
+// GetRaw obtains content of Note's file as it is, without unmarshalling.
+func (self *FilesystemNoteRepository) GetRaw(uid string) (string, error) {
+	content, err := os.ReadFile(self.GetNotePath(uid))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// PutRaw saves content of Note's file as it is, without marshalling.
+func (self *FilesystemNoteRepository) PutRaw(uid string, content string) error {
+	_, err := self.write(self.GetNotePath(uid), content)
+	return err
 }
 
-// Put saves Note to disk.
+// Put saves Note to disk. If the file exists and Note's body is unchanged,
+// only its header block is replaced, so the body is kept byte-for-byte.
+// Existing files keep format of their headers.
 func (self *FilesystemNoteRepository) Put(note Note) (string, error) {
-	marshalled, err := note.ToToml()
+	path := self.GetNotePath(note.Header.Uid)
+	codec := self.getCodec()
+	existing, readErr := os.ReadFile(path)
+	if readErr == nil {
+		spliced, isSpliced, err := SpliceHeader(string(existing), note)
+		if err != nil {
+			return "", errors.Join(err, errors.New("Cannot marshall note"))
+		}
+		if isSpliced {
+			return self.write(path, spliced)
+		}
+		if raw, err := SplitNote(string(existing)); err == nil {
+			codec = raw.Codec
+		}
+	}
+	marshalled, err := note.Marshal(codec)
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot marshall note"))
+	}
+	return self.write(path, marshalled)
+}
+
+func (self *FilesystemNoteRepository) write(path string, content string) (string, error) {
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot save note"))
+	}
+	return path, nil
+}
+
+// Create saves new Note to disk. Unlike Put, it never overwrites existing
+// file: ErrNoteExists is returned instead.
+func (self *FilesystemNoteRepository) Create(note Note) (string, error) {
+	marshalled, err := note.Marshal(self.getCodec())
 	if err != nil {
 		return "", errors.Join(err, errors.New("Cannot marshall note"))
 	}
 	path := self.GetNotePath(note.Header.Uid)
-	err = os.WriteFile(path, []byte(marshalled), 0644)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return "", errors.Join(err, ErrNoteExists)
+	}
 	if err != nil {
 		return "", errors.Join(err, errors.New("Cannot save note"))
 	}
+	_, err = file.WriteString(marshalled)
+	closeErr := file.Close()
+	if err != nil || closeErr != nil {
+		return "", errors.Join(err, closeErr, errors.New("Cannot save note"))
+	}
 	return path, nil
 }
 
@@ -50,11 +121,27 @@ func (self *FilesystemNoteRepository) List() ([]string, error) {
 	return noteUids, nil
 }
 
+// GetModtime returns last modification time of Note's file.
+func (self *FilesystemNoteRepository) GetModtime(uid string) (time.Time, error) {
+	fstat, err := os.Stat(self.GetNotePath(uid))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fstat.ModTime(), nil
+}
+
 // GetNotePath returns absolute path to Note.
 func (repo *FilesystemNoteRepository) GetNotePath(uid string) string {
 	return filepath.Join(repo.RootDir, uid+".md")
 }
 
+func (self *FilesystemNoteRepository) getCodec() HeaderCodec {
+	if self.Codec == nil {
+		return DefaultHeaderCodec()
+	}
+	return self.Codec
+}
+
 // NewFilesystemNoteRepository creates new instance of the repository.
 func NewFilesystemNoteRepository(rootDir string) *FilesystemNoteRepository {
 	return &FilesystemNoteRepository{RootDir: rootDir}
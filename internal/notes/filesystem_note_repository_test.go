@@ -1,6 +1,9 @@
 package notes
 
+import "errors"
 import "os"
+import "path/filepath"
+import "strings"
 import "testing"
 import "time"
 
@@ -35,3 +38,125 @@ func TestListingIgnoresInvalidFilenames(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Len(t, uids, 1)
 }
+
+func TestCreateDoesNotOverwrite(t *testing.T) {
+	// GIVEN
+	tmpdir := t.TempDir()
+	repo := NewFilesystemNoteRepository(tmpdir)
+	when := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+	original := NewNote(when)
+	original.Body = "Original."
+	_, err := repo.Create(original)
+	assert.Nil(t, err)
+
+	// WHEN
+	_, err = repo.Create(NewNote(when))
+
+	// THEN
+	assert.True(t, errors.Is(err, ErrNoteExists))
+	saved, _ := repo.Get(original.Header.Uid)
+	assert.Equal(t, "Original.", saved.Body)
+}
+
+func TestMalformedNotesAreReportedAndSkipped(t *testing.T) {
+	// GIVEN
+	tmpdir := t.TempDir()
+	repo := NewFilesystemNoteRepository(tmpdir)
+	valid := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	valid.Body = "Refers to [[20240102T000000Z]]."
+	repo.Put(valid)
+	malformedPath := filepath.Join(tmpdir, "20240102T000000Z.md")
+	os.WriteFile(malformedPath, []byte("Refers to [[20240101T000000Z]] but has no header."), 0644)
+
+	// WHEN
+	malformed, err := FindMalformedNotes(repo)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, malformed, 1)
+	assert.Equal(t, "20240102T000000Z", malformed[0].Uid)
+	assert.True(t, errors.Is(malformed[0].Err, ErrMissingHeader))
+	assert.Equal(t, malformedPath+":1: Note has no header", malformed[0].Err.Error())
+
+	// WHEN
+	changes, err := LinkNotes(repo, nil)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "20240101T000000Z", changes[0].Uid)
+	content, _ := os.ReadFile(malformedPath)
+	assert.Equal(t, "Refers to [[20240101T000000Z]] but has no header.", string(content))
+
+	// WHEN
+	index := NewInvertedIndex()
+	_, err = index.Update(repo, repo.GetModtime)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Contains(t, index.Notes, "20240101T000000Z")
+	assert.NotContains(t, index.Notes, "20240102T000000Z")
+}
+
+func TestPutKeepsBodyIntact(t *testing.T) {
+	testCases := []struct {
+		testName string
+		opening  string
+		closing  string
+		body     string
+		newline  string
+	}{
+		{"LF", "```toml\n", "```\n", "\n\n# Title\n\nText.  \n\n\n", "\n"},
+		{"CRLF", "\r\n```toml\r\n", "```\r\n", "\r\n# Title\r\n\r\nText.\r\n", "\r\n"},
+		{"No trailing newline", "```toml\n", "```\n", "Text.", "\n"},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// GIVEN
+			tmpdir := t.TempDir()
+			repo := NewFilesystemNoteRepository(tmpdir)
+			note := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			header, _ := note.Header.ToToml()
+			header = strings.ReplaceAll(header, "\n", tc.newline)
+			content := tc.opening + header + tc.closing + tc.body
+			os.WriteFile(repo.GetNotePath(note.Header.Uid), []byte(content), 0644)
+
+			// WHEN
+			loaded, err := repo.Get(note.Header.Uid)
+			assert.Nil(t, err)
+			loaded.Header.RefersTo = []string{"20240202T000000Z"}
+			_, err = repo.Put(loaded)
+
+			// THEN
+			assert.Nil(t, err)
+			saved, _ := os.ReadFile(repo.GetNotePath(note.Header.Uid))
+			expectedHeader := strings.ReplaceAll(
+				header,
+				"refers_to = []",
+				"refers_to = [\"20240202T000000Z\"]",
+			)
+			assert.Equal(t, tc.opening+expectedHeader+tc.closing+tc.body, string(saved))
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestPutRewritesChangedBody(t *testing.T) {
+	// GIVEN
+	tmpdir := t.TempDir()
+	repo := NewFilesystemNoteRepository(tmpdir)
+	note := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	note.Body = "Old."
+	repo.Put(note)
+
+	// WHEN
+	note.Body = "New."
+	_, err := repo.Put(note)
+
+	// THEN
+	assert.Nil(t, err)
+	saved, _ := repo.Get(note.Header.Uid)
+	assert.Equal(t, "New.", saved.Body)
+}
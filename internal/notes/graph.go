@@ -0,0 +1,166 @@
+package notes
+
+import "fmt"
+import "slices"
+import "strings"
+
+// GraphNode is a Note in a Graph.
+type GraphNode struct {
+	Uid       string   `json:"uid"` // revive:disable-line
+	Title     string   `json:"title"`
+	Tags      []string `json:"tags"`
+	Workspace string   `json:"workspace"`
+}
+
+// GraphEdge is a reference from Source Note to Target Note.
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Graph represents Notes and references between them.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// BuildGraph creates Graph of Notes in indexed workspaces. Nodes are
+// attributed with names of workspaces they come from. References to Notes
+// which are not indexed, e.g. missing or malformed, are skipped. Nodes and
+// edges are sorted.
+func BuildGraph(indexed *IndexedWorkspaces) Graph {
+	graph := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	uids := indexed.List()
+	for _, uid := range uids {
+		nt, workspace, _ := indexed.Get(uid)
+		tags := nt.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		graph.Nodes = append(
+			graph.Nodes,
+			GraphNode{Uid: uid, Title: nt.Title, Tags: tags, Workspace: workspace},
+		)
+	}
+	for _, source := range uids {
+		for _, target := range indexed.RefersTo(source) {
+			graph.Edges = append(graph.Edges, GraphEdge{Source: source, Target: target})
+		}
+	}
+	return graph
+}
+
+// FindOrphans returns sorted Uids of indexed Notes which neither refer to nor
+// are referred from any other existing Note. References of a Note to itself
+// or to missing Notes do not count.
+func FindOrphans(indexed *IndexedWorkspaces) []string {
+	orphans := []string{}
+	for _, uid := range indexed.List() {
+		isLinked := slices.ContainsFunc(indexed.Links(uid), func(other string) bool { return other != uid })
+		if !isLinked {
+			orphans = append(orphans, uid)
+		}
+	}
+	return orphans
+}
+
+// Neighbour is a Note found within some distance from another one.
+type Neighbour struct {
+	Uid      string // revive:disable-line
+	Title    string
+	Distance int
+}
+
+// FindNeighbours follows links of indexed Notes, in both directions, and
+// returns Notes which are at most depth hops away from Note with given Uid.
+// Neighbours are ordered by distance, then by Uid.
+func FindNeighbours(indexed *IndexedWorkspaces, uid string, depth int) ([]Neighbour, error) {
+	neighbours := []Neighbour{}
+	err := walkLinks(indexed, uid, func(current string, distance int, _ string) bool {
+		if distance > 0 {
+			nt, _, _ := indexed.Get(current)
+			neighbours = append(
+				neighbours,
+				Neighbour{Uid: current, Title: nt.Title, Distance: distance},
+			)
+		}
+		// Links of the farthest neighbours would lead too far.
+		return distance < depth
+	})
+	if err != nil {
+		return []Neighbour{}, err
+	}
+	slices.SortFunc(neighbours, func(lhs, rhs Neighbour) int {
+		if lhs.Distance != rhs.Distance {
+			return lhs.Distance - rhs.Distance
+		}
+		return strings.Compare(lhs.Uid, rhs.Uid)
+	})
+	return neighbours, nil
+}
+
+// FindPath returns Uids of the shortest sequence of linked Notes starting with
+// Note of Uid from and ending with Note of Uid to. Links are followed in both
+// directions. If there is no such path, empty slice is returned.
+func FindPath(indexed *IndexedWorkspaces, from string, to string) ([]string, error) {
+	previous := make(map[string]string)
+	isFound := false
+	err := walkLinks(indexed, from, func(current string, _ int, prev string) bool {
+		previous[current] = prev
+		if current == to {
+			isFound = true
+			return false
+		}
+		return !isFound
+	})
+	if err != nil {
+		return []string{}, err
+	}
+	if !isFound {
+		return []string{}, nil
+	}
+
+	path := []string{}
+	for uid := to; uid != ""; uid = previous[uid] {
+		path = append(path, uid)
+	}
+	slices.Reverse(path)
+	return path, nil
+}
+
+// walkLinks performs breadth first search over links between indexed Notes,
+// starting from Note of given Uid. Every reached Uid is passed to visit
+// together with distance and Uid of the Note it was reached from. Links of a
+// Note are followed only if visit returns true.
+func walkLinks(
+	indexed *IndexedWorkspaces,
+	start string,
+	visit func(uid string, distance int, previous string) bool,
+) error {
+	if _, _, ok := indexed.Get(start); !ok {
+		return fmt.Errorf("Note with UID '%s' does not exist", start)
+	}
+
+	type step struct {
+		uid      string
+		distance int
+		previous string
+	}
+	queue := []step{{uid: start, distance: 0, previous: ""}}
+	seen := map[string]bool{start: true}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if !visit(current.uid, current.distance, current.previous) {
+			continue
+		}
+		for _, uid := range indexed.Links(current.uid) {
+			if seen[uid] {
+				continue
+			}
+			seen[uid] = true
+			queue = append(queue, step{uid: uid, distance: current.distance + 1, previous: current.uid})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package notes
+
+import "fmt"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func TestBuildGraph(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note1.Header.Title = "First"
+	note1.Header.Tags = []string{"topic:go"}
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Body = fmt.Sprintf("Refers to [[%s]] and [[20010101T010101Z]]", note1.Header.Uid)
+
+	mainRepo := NewInMemoryNoteRepository()
+	mainRepo.Put(note1)
+	workRepo := NewInMemoryNoteRepository()
+	workRepo.Put(note2)
+	indexed := NewIndexedWorkspaces()
+	indexed.Add("main", indexRepository(t, mainRepo))
+	indexed.Add("work", indexRepository(t, workRepo))
+
+	// WHEN
+	actual := BuildGraph(indexed)
+
+	// THEN
+	expected := Graph{
+		Nodes: []GraphNode{
+			{Uid: note1.Header.Uid, Title: "First", Tags: []string{"topic:go"}, Workspace: "main"},
+			{Uid: note2.Header.Uid, Title: "", Tags: []string{}, Workspace: "work"},
+		},
+		Edges: []GraphEdge{
+			{Source: note2.Header.Uid, Target: note1.Header.Uid},
+		},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func indexRepository(t *testing.T, repository INoteRepository) *InvertedIndex {
+	index := NewInvertedIndex()
+	_, err := index.Update(repository, modtimesOf(map[string]time.Time{}))
+	assert.Nil(t, err)
+	return &index
+}
+
+func linkedChain(t *testing.T) (*IndexedWorkspaces, []string) {
+	// 1 <- 2 <- 3 <- 4, and 5 is isolated.
+	repository := NewInMemoryNoteRepository()
+	uids := []string{}
+	for idx := 1; idx <= 5; idx++ {
+		nt := NewNote(time.Date(1990+idx, 1, 1, 1, 1, 1, 0, time.UTC))
+		if idx > 1 && idx < 5 {
+			nt.Body = fmt.Sprintf("Refers to [[%s]]", uids[idx-2])
+		}
+		repository.Put(nt)
+		uids = append(uids, nt.Header.Uid)
+	}
+	indexed := NewIndexedWorkspaces()
+	indexed.Add("main", indexRepository(t, repository))
+	return indexed, uids
+}
+
+func TestFindNeighbours(t *testing.T) {
+	// GIVEN
+	indexed, uids := linkedChain(t)
+
+	// WHEN
+	actual, err := FindNeighbours(indexed, uids[1], 2)
+
+	// THEN
+	assert.Nil(t, err)
+	expected := []Neighbour{
+		{Uid: uids[0], Distance: 1},
+		{Uid: uids[2], Distance: 1},
+		{Uid: uids[3], Distance: 2},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestFindNeighboursOrder(t *testing.T) {
+	// GIVEN
+	// 5 -> 1 -> 4 and 5 -> 2 -> 3, so 4 is reached before 3.
+	links := map[int]int{5: 1, 1: 4, 2: 3}
+	repository := NewInMemoryNoteRepository()
+	uidOf := func(idx int) string { return fmt.Sprintf("199%d0101T010101Z", idx) }
+	for idx := 1; idx <= 5; idx++ {
+		nt := NewNote(time.Date(1990+idx, 1, 1, 1, 1, 1, 0, time.UTC))
+		if target, ok := links[idx]; ok {
+			nt.Body = fmt.Sprintf("Refers to [[%s]]", uidOf(target))
+		}
+		if idx == 5 {
+			nt.Body += fmt.Sprintf(" and [[%s]]", uidOf(2))
+		}
+		repository.Put(nt)
+	}
+	indexed := NewIndexedWorkspaces()
+	indexed.Add("main", indexRepository(t, repository))
+
+	// WHEN
+	nearest, nearestErr := FindNeighbours(indexed, uidOf(5), 1)
+	all, allErr := FindNeighbours(indexed, uidOf(5), 2)
+
+	// THEN
+	assert.Nil(t, nearestErr)
+	assert.Equal(t, []Neighbour{{Uid: uidOf(1), Distance: 1}, {Uid: uidOf(2), Distance: 1}}, nearest)
+	assert.Nil(t, allErr)
+	expected := []Neighbour{
+		{Uid: uidOf(1), Distance: 1},
+		{Uid: uidOf(2), Distance: 1},
+		{Uid: uidOf(3), Distance: 2},
+		{Uid: uidOf(4), Distance: 2},
+	}
+	assert.Equal(t, expected, all)
+}
+
+func TestFindPath(t *testing.T) {
+	// GIVEN
+	indexed, uids := linkedChain(t)
+
+	// WHEN
+	actual, err := FindPath(indexed, uids[0], uids[3])
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, uids[:4], actual)
+
+	// WHEN
+	actual, err = FindPath(indexed, uids[0], uids[4])
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Empty(t, actual)
+}
+
+func TestFindOrphans(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Body = fmt.Sprintf("Refers to [[%s]]", note1.Header.Uid)
+	note3 := NewNote(time.Date(1993, 3, 3, 3, 3, 3, 0, time.UTC))
+	note3.Body = fmt.Sprintf("Refers to itself [[%s]]", note3.Header.Uid)
+	note4 := NewNote(time.Date(1994, 4, 4, 4, 4, 4, 0, time.UTC))
+	note4.Body = "Refers to missing [[20010101T010101Z]]"
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+	repository.Put(note3)
+	repository.Put(note4)
+	indexed := NewIndexedWorkspaces()
+	indexed.Add("main", indexRepository(t, repository))
+
+	// WHEN
+	actual := FindOrphans(indexed)
+
+	// THEN
+	assert.Equal(t, []string{note3.Header.Uid, note4.Header.Uid}, actual)
+}
@@ -1,5 +1,7 @@
 package notes
 
+import "errors"
+import "reflect"
 import "regexp"
 import "slices"
 import "sort"
@@ -9,16 +11,26 @@ import "time"
 import "github.com/BurntSushi/toml"
 
 // Header is a metadata put on top of the note. It is marshalled as a toml
-// block.
+// block or as front matter, see HeaderCodec.
 type Header struct {
-	Title        string   `toml:"title"`
-	Timestamp    string   `toml:"timestamp"`
-	Uid          string   `toml:"uid"` // revive:disable
-	Tags         []string `toml:"tags"`
-	ReferredFrom []string `toml:"referred_from"`
-	RefersTo     []string `toml:"refers_to"`
+	Title        string   `toml:"title" json:"title" yaml:"title"`
+	Timestamp    string   `toml:"timestamp" json:"timestamp" yaml:"timestamp"`
+	Uid          string   `toml:"uid" json:"uid" yaml:"uid"` // revive:disable
+	Tags         []string `toml:"tags" json:"tags" yaml:"tags,flow"`
+	ReferredFrom []string `toml:"referred_from" json:"referred_from" yaml:"referred_from,flow"`
+	RefersTo     []string `toml:"refers_to" json:"refers_to" yaml:"refers_to,flow"`
+	// IndexedIn lists names of indices the Note is a member of. It is left out
+	// of marshalled Header when empty.
+	IndexedIn []string `toml:"indexed_in,omitempty" json:"indexed_in,omitempty" yaml:"indexed_in,omitempty,flow"`
+	// Extra holds fields put in the header by user, e.g. `status = "draft"`,
+	// so they survive rewriting the Note. They are marshalled after the known
+	// fields, ordered by name.
+	Extra map[string]any `toml:"-" json:"extra,omitempty" yaml:"-"`
 }
 
+// builtinFields are names of marshalled fields of Header, other than Extra.
+var builtinFields = []string{"title", "timestamp", "uid", "tags", "referred_from", "refers_to", "indexed_in"}
+
 // Equal checks equality of two Headers, i.e. same values and same order of
 // them.
 func (lhs *Header) Equal(rhs Header) bool {
@@ -28,7 +40,9 @@ func (lhs *Header) Equal(rhs Header) bool {
 	tagsEq := slices.Equal(lhs.Tags, rhs.Tags)
 	refFromEq := slices.Equal(lhs.ReferredFrom, rhs.ReferredFrom)
 	refToEq := slices.Equal(lhs.RefersTo, rhs.RefersTo)
-	return titlesEq && timestampEq && uidEq && tagsEq && refFromEq && refToEq
+	indexedInEq := slices.Equal(lhs.IndexedIn, rhs.IndexedIn)
+	extraEq := (len(lhs.Extra) == 0 && len(rhs.Extra) == 0) || reflect.DeepEqual(lhs.Extra, rhs.Extra)
+	return titlesEq && timestampEq && uidEq && tagsEq && refFromEq && refToEq && indexedInEq && extraEq
 }
 
 // ToToml marshalls Header.
@@ -37,7 +51,14 @@ func (self *Header) ToToml() (res string, err error) {
 	if err != nil {
 		return "", err
 	}
-	return string(marshalled), nil
+	if len(self.Extra) == 0 {
+		return string(marshalled), nil
+	}
+	extra, err := toml.Marshal(toTomlValue(self.Extra))
+	if err != nil {
+		return "", errors.Join(err, errors.New("Cannot marshall extra fields of header"))
+	}
+	return string(marshalled) + string(extra), nil
 }
 
 // Arrange enforces unified style of Headers. It modifies Header in place.
@@ -49,6 +70,21 @@ func (self *Header) Arrange() {
 	sort.Sort(sort.StringSlice(self.Tags))
 	sort.Sort(sort.StringSlice(self.ReferredFrom))
 	sort.Sort(sort.StringSlice(self.RefersTo))
+	sort.Sort(sort.StringSlice(self.IndexedIn))
+}
+
+// GetTime returns moment of Note creation, read from Timestamp. If Timestamp
+// is malformed, Uid is used instead.
+func (self *Header) GetTime() (time.Time, error) {
+	when, err := time.Parse(time.RFC3339, self.Timestamp)
+	if err == nil {
+		return when, nil
+	}
+	when, uidErr := time.Parse("20060102T150405Z", self.Uid)
+	if uidErr == nil {
+		return when, nil
+	}
+	return time.Time{}, errors.Join(err, uidErr, errors.New("Cannot read time of note"))
 }
 
 // NewHeader creates new Header.
@@ -65,6 +101,16 @@ func NewHeader(when time.Time) Header {
 	}
 }
 
+// FindFreeTime returns the earliest moment, not before when, giving Uid for
+// which isTaken is false. As Uids have one second resolution, colliding
+// moments are bumped by whole seconds, so the result is deterministic.
+func FindFreeTime(when time.Time, isTaken func(uid string) bool) time.Time {
+	for isTaken(NewHeader(when).Uid) {
+		when = when.Truncate(time.Second).Add(time.Second)
+	}
+	return when
+}
+
 // GetUidRegexp creates regexp matching Note Uid, i.e. filenames and references
 // of other Notes within Note's body.
 func GetUidRegexp() *regexp.Regexp {
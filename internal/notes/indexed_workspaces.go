@@ -0,0 +1,84 @@
+package notes
+
+import "slices"
+
+// IndexedWorkspaces joins InvertedIndexes of many named repositories, e.g. of
+// all workspaces, so Notes and links between them can be looked up without
+// reading Notes themselves. Like in CompositeNoteRepository, if the same Uid
+// is indexed in many of them, the first one added wins.
+type IndexedWorkspaces struct {
+	indices      map[string]*InvertedIndex
+	owners       map[string]string
+	referredFrom ReferenceMap
+}
+
+// Add attaches index under given name.
+func (self *IndexedWorkspaces) Add(name string, index *InvertedIndex) {
+	self.indices[name] = index
+	for uid := range index.Notes {
+		if _, isOwned := self.owners[uid]; !isOwned {
+			self.owners[uid] = name
+		}
+	}
+	for target, sources := range index.ReferredFrom {
+		for _, source := range sources {
+			self.referredFrom[target] = insertSorted(self.referredFrom[target], source)
+		}
+	}
+}
+
+// List returns sorted Uids of all indexed Notes.
+func (self *IndexedWorkspaces) List() []string {
+	uids := []string{}
+	for uid := range self.owners {
+		uids = append(uids, uid)
+	}
+	slices.Sort(uids)
+	return uids
+}
+
+// Get returns indexed digest of Note with given Uid and name of index it
+// comes from.
+func (self *IndexedWorkspaces) Get(uid string) (IndexedNote, string, bool) {
+	owner, ok := self.owners[uid]
+	if !ok {
+		return IndexedNote{}, "", false
+	}
+	return self.indices[owner].Notes[uid], owner, true
+}
+
+// RefersTo returns sorted Uids of indexed Notes referred from Note with given
+// Uid.
+func (self *IndexedWorkspaces) RefersTo(uid string) []string {
+	indexed, _, _ := self.Get(uid)
+	refersTo := []string{}
+	for _, target := range indexed.RefersTo {
+		if _, ok := self.owners[target]; ok {
+			refersTo = append(refersTo, target)
+		}
+	}
+	return refersTo
+}
+
+// ReferredFrom returns sorted Uids of indexed Notes referring to Note with
+// given Uid.
+func (self *IndexedWorkspaces) ReferredFrom(uid string) []string {
+	return slices.Clone(self.referredFrom[uid])
+}
+
+// Links returns sorted Uids of indexed Notes linked with Note of given Uid in
+// any direction.
+func (self *IndexedWorkspaces) Links(uid string) []string {
+	links := append(self.RefersTo(uid), self.referredFrom[uid]...)
+	slices.Sort(links)
+	return slices.Compact(links)
+}
+
+// NewIndexedWorkspaces creates new, empty instance.
+func NewIndexedWorkspaces() *IndexedWorkspaces {
+	return &IndexedWorkspaces{
+		indices:      make(map[string]*InvertedIndex),
+		owners:       make(map[string]string),
+		referredFrom: make(ReferenceMap),
+	}
+}
@@ -0,0 +1,343 @@
+package notes
+
+import "encoding/json"
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "slices"
+import "strings"
+import "time"
+import "unicode"
+
+// invertedIndexVersion is bumped whenever layout of InvertedIndex changes, so
+// outdated files are rebuilt instead of being misread.
+const invertedIndexVersion = 2
+
+// IndexedNote is a digest of a Note, just enough to answer queries without
+// reading the Note itself.
+type IndexedNote struct {
+	Modtime   time.Time `json:"modtime"`
+	Title     string    `json:"title"`
+	Timestamp string    `json:"timestamp"`
+	Tags      []string  `json:"tags"`
+	RefersTo  []string  `json:"refers_to"`
+	Terms     []string  `json:"terms"`
+}
+
+// InvertedIndex maps terms, tags and links to Uids of Notes containing them.
+// It is kept on disk and updated incrementally, based on modification times
+// of Notes.
+type InvertedIndex struct {
+	Version      int                    `json:"version"`
+	Notes        map[string]IndexedNote `json:"notes"`
+	Terms        ReferenceMap           `json:"terms"`
+	Tags         ReferenceMap           `json:"tags"`
+	ReferredFrom ReferenceMap           `json:"referred_from"`
+	// sortedTerms are keys of Terms, sorted, so terms sharing a prefix can be
+	// found with binary search. Built on demand, dropped on every change.
+	sortedTerms []string
+}
+
+// NewInvertedIndex creates empty InvertedIndex.
+func NewInvertedIndex() InvertedIndex {
+	return InvertedIndex{
+		Version:      invertedIndexVersion,
+		Notes:        make(map[string]IndexedNote),
+		Terms:        make(ReferenceMap),
+		Tags:         make(ReferenceMap),
+		ReferredFrom: make(ReferenceMap),
+	}
+}
+
+// Tokenize splits text into lowercase words, i.e. runs of letters and digits.
+// Repeated words are returned once, sorted.
+func Tokenize(text string) []string {
+	isSeparator := func(r rune) bool {
+		return !isWordRune(r)
+	}
+	tokens := strings.FieldsFunc(strings.ToLower(text), isSeparator)
+	slices.Sort(tokens)
+	return slices.Compact(tokens)
+}
+
+// isWordRune tells if rune is a part of a word, as split by Tokenize.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Update brings InvertedIndex in line with repository. Only Notes modified
+// since they were indexed are read. Returns number of added, changed or
+// removed entries. Malformed Notes are left out of InvertedIndex.
+func (self *InvertedIndex) Update(
+	repository INoteRepository,
+	modtime func(uid string) (time.Time, error),
+) (int, error) {
+	uids, err := repository.List()
+	if err != nil {
+		return 0, errors.Join(err, errors.New("Cannot list note uids"))
+	}
+
+	listed := make(map[string]bool)
+	for _, uid := range uids {
+		listed[uid] = true
+	}
+
+	changes := 0
+	for indexedUid := range self.Notes {
+		if !listed[indexedUid] {
+			self.remove(indexedUid)
+			changes++
+		}
+	}
+
+	for _, uid := range uids {
+		mt, err := modtime(uid)
+		if err != nil {
+			return changes, errors.Join(err, fmt.Errorf("Cannot get modification time of note with UID '%s'", uid))
+		}
+		indexed, isIndexed := self.Notes[uid]
+		if isIndexed && indexed.Modtime.Equal(mt) {
+			continue
+		}
+		nt, err := repository.Get(uid)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			if isIndexed {
+				self.remove(uid)
+				changes++
+			}
+			continue
+		}
+		if err != nil {
+			return changes, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+		}
+		self.remove(uid)
+		self.add(uid, nt, mt)
+		changes++
+	}
+	return changes, nil
+}
+
+// Candidates returns sorted Uids of Notes which may match the query. Every
+// Note matching the query is among candidates, but not every candidate
+// matches: use SearchQuery.Matches on actual Note to be sure. Words of terms
+// are looked up as prefixes of indexed terms, as terms match at beginning of
+// words, e.g. `err` finds `errors`.
+func (self *InvertedIndex) Candidates(query SearchQuery) []string {
+	var candidates []string
+	for _, term := range query.Terms {
+		for _, word := range Tokenize(term) {
+			found := []string{}
+			for _, indexedTerm := range self.findTermsWithPrefix(word) {
+				found = append(found, self.Terms[indexedTerm]...)
+			}
+			if candidates == nil {
+				candidates = found
+			} else {
+				candidates = intersect(candidates, found)
+			}
+		}
+	}
+	if candidates == nil {
+		candidates = []string{}
+		for uid := range self.Notes {
+			candidates = append(candidates, uid)
+		}
+	}
+	slices.Sort(candidates)
+	return slices.Compact(candidates)
+}
+
+// findTermsWithPrefix returns indexed terms starting with prefix.
+func (self *InvertedIndex) findTermsWithPrefix(prefix string) []string {
+	if self.sortedTerms == nil {
+		self.sortedTerms = make([]string, 0, len(self.Terms))
+		for term := range self.Terms {
+			self.sortedTerms = append(self.sortedTerms, term)
+		}
+		slices.Sort(self.sortedTerms)
+	}
+	first, _ := slices.BinarySearch(self.sortedTerms, prefix)
+	last := first
+	for last < len(self.sortedTerms) && strings.HasPrefix(self.sortedTerms[last], prefix) {
+		last++
+	}
+	return self.sortedTerms[first:last]
+}
+
+// Tagged returns sorted Uids of Notes with given tag.
+func (self *InvertedIndex) Tagged(tag string) []string {
+	return slices.Clone(self.Tags[strings.ToLower(tag)])
+}
+
+// Backlinks returns sorted Uids of Notes referring to Note with given Uid.
+func (self *InvertedIndex) Backlinks(uid string) []string {
+	return slices.Clone(self.ReferredFrom[uid])
+}
+
+func (self *InvertedIndex) add(uid string, note Note, modtime time.Time) {
+	tags := []string{}
+	for _, tag := range note.Header.Tags {
+		tags = append(tags, strings.ToLower(tag))
+	}
+	refersTo := FindUids(note.Body)
+	slices.Sort(refersTo)
+	refersTo = slices.Compact(refersTo)
+	if refersTo == nil {
+		refersTo = []string{}
+	}
+	terms := Tokenize(strings.Join(
+		[]string{note.Header.Title, strings.Join(note.Header.Tags, " "), note.Body},
+		" ",
+	))
+
+	self.Notes[uid] = IndexedNote{
+		Modtime:   modtime,
+		Title:     note.Header.Title,
+		Timestamp: note.Header.Timestamp,
+		Tags:      tags,
+		RefersTo:  refersTo,
+		Terms:     terms,
+	}
+	self.sortedTerms = nil
+	for _, term := range terms {
+		self.Terms[term] = insertSorted(self.Terms[term], uid)
+	}
+	for _, tag := range tags {
+		self.Tags[tag] = insertSorted(self.Tags[tag], uid)
+	}
+	for _, ref := range refersTo {
+		self.ReferredFrom[ref] = insertSorted(self.ReferredFrom[ref], uid)
+	}
+}
+
+func (self *InvertedIndex) remove(uid string) {
+	indexed, ok := self.Notes[uid]
+	if !ok {
+		return
+	}
+	removeFrom := func(refs ReferenceMap, keys []string) {
+		for _, key := range keys {
+			refs[key] = slices.DeleteFunc(refs[key], func(item string) bool { return item == uid })
+			if len(refs[key]) == 0 {
+				delete(refs, key)
+			}
+		}
+	}
+	removeFrom(self.Terms, indexed.Terms)
+	removeFrom(self.Tags, indexed.Tags)
+	removeFrom(self.ReferredFrom, indexed.RefersTo)
+	delete(self.Notes, uid)
+	self.sortedTerms = nil
+}
+
+func insertSorted(items []string, item string) []string {
+	idx, found := slices.BinarySearch(items, item)
+	if found {
+		return items
+	}
+	return slices.Insert(items, idx, item)
+}
+
+func intersect(lhs []string, rhs []string) []string {
+	inRhs := make(map[string]bool)
+	for _, item := range rhs {
+		inRhs[item] = true
+	}
+	common := []string{}
+	for _, item := range lhs {
+		if inRhs[item] {
+			common = append(common, item)
+		}
+	}
+	return common
+}
+
+// GetInvertedIndexFromFile reads InvertedIndex from file. If file does not
+// exist or was written by incompatible version, empty index is returned, so it
+// can be rebuilt with Update.
+func GetInvertedIndexFromFile(path string) (InvertedIndex, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewInvertedIndex(), nil
+	}
+	if err != nil {
+		return InvertedIndex{}, errors.Join(err, errors.New("Cannot read inverted index"))
+	}
+
+	index := NewInvertedIndex()
+	err = json.Unmarshal(content, &index)
+	if err != nil {
+		return InvertedIndex{}, errors.Join(err, errors.New("Cannot unmarshall inverted index"))
+	}
+	if index.Version != invertedIndexVersion {
+		return NewInvertedIndex(), nil
+	}
+	return index, nil
+}
+
+// PutInvertedIndexToFile saves InvertedIndex to file. File is replaced
+// atomically, so concurrent readers never see partially written index. As the
+// index can always be rebuilt, directory gets .gitignore excluding it from
+// version control.
+func PutInvertedIndexToFile(path string, index InvertedIndex) error {
+	content, err := json.Marshal(index)
+	if err != nil {
+		return errors.Join(err, errors.New("Cannot marshall inverted index"))
+	}
+
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0744)
+	if err != nil {
+		return errors.Join(err, errors.New("Cannot create directory for inverted index"))
+	}
+
+	gitignorePath := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignorePath); errors.Is(err, os.ErrNotExist) {
+		err = os.WriteFile(gitignorePath, []byte(filepath.Base(path)+"*\n"), 0644)
+		if err != nil {
+			return errors.Join(err, errors.New("Cannot exclude inverted index from version control"))
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Join(err, errors.New("Cannot save inverted index"))
+	}
+	defer os.Remove(tmp.Name())
+	err = tmp.Chmod(0644)
+	if err == nil {
+		_, err = tmp.Write(content)
+	}
+	closeErr := tmp.Close()
+	if err != nil || closeErr != nil {
+		return errors.Join(err, closeErr, errors.New("Cannot save inverted index"))
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return errors.Join(err, errors.New("Cannot save inverted index"))
+	}
+	return nil
+}
+
+// UpdateInvertedIndexFile reads InvertedIndex from path, updates it with
+// Notes from repository and saves it back if anything changed.
+func UpdateInvertedIndexFile(repository *FilesystemNoteRepository, path string) (InvertedIndex, error) {
+	index, err := GetInvertedIndexFromFile(path)
+	if err != nil {
+		return InvertedIndex{}, err
+	}
+	changes, err := index.Update(repository, repository.GetModtime)
+	if err != nil {
+		return InvertedIndex{}, errors.Join(err, errors.New("Cannot update inverted index"))
+	}
+	if changes == 0 {
+		return index, nil
+	}
+	err = PutInvertedIndexToFile(path, index)
+	if err != nil {
+		return InvertedIndex{}, err
+	}
+	return index, nil
+}
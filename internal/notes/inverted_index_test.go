@@ -0,0 +1,125 @@
+package notes
+
+import "path/filepath"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func modtimesOf(times map[string]time.Time) func(string) (time.Time, error) {
+	return func(uid string) (time.Time, error) {
+		return times[uid], nil
+	}
+}
+
+func TestInvertedIndexUpdate(t *testing.T) {
+	// GIVEN
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note1.Header.Title = "Errors in Go"
+	note1.Header.Tags = []string{"topic:go"}
+	note1.Body = "Go favours explicit error handling."
+
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Body = "See [[" + note1.Header.Uid + "]]."
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+	modtimes := map[string]time.Time{note1.Header.Uid: t0, note2.Header.Uid: t0}
+
+	index := NewInvertedIndex()
+
+	// WHEN
+	changes, err := index.Update(repository, modtimesOf(modtimes))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, []string{note1.Header.Uid}, index.Tagged("topic:go"))
+	assert.Equal(t, []string{note2.Header.Uid}, index.Backlinks(note1.Header.Uid))
+
+	// WHEN nothing was modified.
+	changes, err = index.Update(repository, modtimesOf(modtimes))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+
+	// WHEN one note is modified and other is gone.
+	note1.Body = "Nothing about that language anymore."
+	note1.Header.Tags = []string{}
+	repository = NewInMemoryNoteRepository()
+	repository.Put(note1)
+	modtimes = map[string]time.Time{note1.Header.Uid: t0.Add(time.Minute)}
+	changes, err = index.Update(repository, modtimesOf(modtimes))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 2, changes)
+	assert.Empty(t, index.Tagged("topic:go"))
+	assert.Empty(t, index.Backlinks(note1.Header.Uid))
+	assert.NotContains(t, index.Terms, "favours")
+}
+
+func TestInvertedIndexCandidates(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note1.Body = "Go favours explicit error handling."
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Body = "Python raises errors."
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+
+	index := NewInvertedIndex()
+	index.Update(repository, modtimesOf(map[string]time.Time{}))
+
+	testCases := []struct {
+		testName string
+		query    string
+		expected []string
+	}{
+		{"Word in both notes", "error", []string{note1.Header.Uid, note2.Header.Uid}},
+		{"Phrase", `"error handling"`, []string{note1.Header.Uid}},
+		{"Beginning of word", "expl", []string{note1.Header.Uid}},
+		{"Middle of word", "rror", []string{}},
+		{"Unknown word", "rust", []string{}},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			query, _ := ParseSearchQuery(tc.query, false)
+
+			// WHEN
+			actual := index.Candidates(query)
+
+			// THEN
+			assert.Equal(t, tc.expected, actual)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestInvertedIndexFile(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "index", ".inverted_index.json")
+	note := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note.Header.Tags = []string{"topic:go"}
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note)
+
+	given := NewInvertedIndex()
+	given.Update(repository, modtimesOf(map[string]time.Time{}))
+
+	// WHEN
+	err := PutInvertedIndexToFile(path, given)
+	assert.Nil(t, err)
+	actual, err := GetInvertedIndexFromFile(path)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, given, actual)
+}
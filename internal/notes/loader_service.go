@@ -1,25 +1,205 @@
 package notes
 
 import "errors"
-import "regexp"
+import "fmt"
 import "strings"
 
-import "github.com/BurntSushi/toml"
+// ErrMissingHeader signals that Note does not start with header in any of
+// supported formats, see HeaderCodec.
+var ErrMissingHeader = errors.New("Note has no header")
+
+// ErrUnclosedHeader signals that header of Note is never closed.
+var ErrUnclosedHeader = errors.New("Note header is not closed")
+
+// ErrInvalidHeader signals that header of Note cannot be decoded, e.g. it is
+// not valid toml.
+var ErrInvalidHeader = errors.New("Note header is malformed")
+
+// ParseError describes why Note could not be loaded and where the problem is.
+type ParseError struct {
+	// Path to file of the Note, if known.
+	Path string
+	// Line of the problem, counting from 1, or 0 if unknown.
+	Line int
+	// Err is one of ErrMissingHeader, ErrUnclosedHeader or ErrInvalidHeader,
+	// possibly joined with details.
+	Err error
+}
+
+// Error formats ParseError as `path:line: problem`, skipping unknown parts.
+func (self *ParseError) Error() string {
+	location := self.Path
+	if self.Line > 0 && location != "" {
+		location = fmt.Sprintf("%s:%d", location, self.Line)
+	} else if self.Line > 0 {
+		location = fmt.Sprint(self.Line)
+	}
+	message := strings.ReplaceAll(self.Err.Error(), "\n", ": ")
+	if location == "" {
+		return message
+	}
+	return location + ": " + message
+}
+
+// WithPath returns copy of ParseError located in file with given path, e.g.
+// relative instead of absolute.
+func (self *ParseError) WithPath(path string) *ParseError {
+	located := *self
+	located.Path = path
+	return &located
+}
+
+// Unwrap allows matching ParseError with errors.Is against ErrMissingHeader
+// and others.
+func (self *ParseError) Unwrap() error {
+	return self.Err
+}
+
+// RawNote is marshalled Note split into header and body, not parsed yet.
+// Joining Opening, Header, Closing and Body gives back marshalled Note.
+type RawNote struct {
+	// Codec is the format of the header.
+	Codec HeaderCodec
+	// Opening holds blank lines preceding the header, if any, and the opening
+	// fence.
+	Opening string
+	Header  string
+	// Closing is the closing fence, with its line ending.
+	Closing string
+	// HeaderLine is a line of the opening fence.
+	HeaderLine int
+	Body       string
+	// BodyLine is a line at which Body starts.
+	BodyLine int
+}
+
+// SplitNote finds header at the top of marshalled Note, optionally preceded
+// by blank lines. Format of the header is detected by its opening fence, e.g.
+// ```toml or ---. Header ends at the first line holding only the closing
+// fence, so values may contain backticks.
+func SplitNote(content string) (RawNote, error) {
+	lines := strings.SplitAfter(content, "\n")
+	idx := 0
+	for idx < len(lines) && strings.TrimSpace(lines[idx]) == "" {
+		idx++
+	}
+	var codec HeaderCodec
+	if idx < len(lines) {
+		codec = detectHeaderCodec(lines[idx])
+	}
+	if codec == nil {
+		return RawNote{}, &ParseError{Line: min(idx+1, len(lines)), Err: ErrMissingHeader}
+	}
+	headerLine := idx + 1
+	for end := idx + 1; end < len(lines); end++ {
+		if strings.TrimRight(lines[end], " \t\r\n") != codec.Closing() {
+			continue
+		}
+		return RawNote{
+			Codec:      codec,
+			Opening:    strings.Join(lines[:idx+1], ""),
+			Header:     strings.Join(lines[idx+1:end], ""),
+			Closing:    lines[end],
+			HeaderLine: headerLine,
+			Body:       strings.Join(lines[end+1:], ""),
+			BodyLine:   end + 2,
+		}, nil
+	}
+	return RawNote{}, &ParseError{Line: headerLine, Err: ErrUnclosedHeader}
+}
 
 // UnmarshallNote loads Note from string. This function expects that Note's
-// Header was marshalled to toml string, wrapped in ```toml``` fenced block, as
-// commonly done in markdown.
+// Header was marshalled in one of formats of HeaderCodec, most commonly toml
+// wrapped in ```toml``` fenced block. Fields unknown to Header are kept in
+// Header.Extra. Problems are reported as *ParseError.
 func UnmarshallNote(content string) (res Note, err error) {
-	zkRe := regexp.MustCompile("(?s)```toml\n(?P<header>[^`]+)```\n*(?P<body>.*)\n?")
-	matched := zkRe.FindStringSubmatch(string(content))
-	headerRaw := matched[zkRe.SubexpIndex("header")]
-	bodyRaw := strings.TrimSpace(matched[zkRe.SubexpIndex("body")])
+	raw, err := SplitNote(content)
+	if err != nil {
+		return Note{}, err
+	}
+
+	header, err := raw.Codec.Unmarshal(raw.Header)
+	if err != nil {
+		line := raw.HeaderLine
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			line += parseErr.Line
+			err = parseErr.Err
+		}
+		return Note{}, &ParseError{Line: line, Err: errors.Join(ErrInvalidHeader, err)}
+	}
+
+	return Note{Header: header, Body: strings.TrimSpace(raw.Body)}, nil
+}
 
-	var header Header
-	_, err = toml.Decode(headerRaw, &header)
+// SpliceHeader replaces header block of marshalled Note with Header of
+// given Note, leaving everything around it byte-for-byte intact, including
+// blank lines and line endings of the body. New header keeps the format and
+// follows line endings of the opening fence. Returns false if content is not
+// a Note with the same body, so it has to be marshalled from scratch.
+func SpliceHeader(content string, note Note) (string, bool, error) {
+	raw, err := SplitNote(content)
+	if err != nil || strings.TrimSpace(raw.Body) != note.Body {
+		return "", false, nil
+	}
+	header, err := raw.Codec.Marshal(note.Header)
 	if err != nil {
-		return Note{}, errors.Join(err, errors.New("Cannot unmarshall note"))
+		return "", false, err
+	}
+	if strings.HasSuffix(raw.Opening, "\r\n") {
+		header = strings.ReplaceAll(header, "\n", "\r\n")
 	}
+	return raw.Opening + header + raw.Closing + raw.Body, true, nil
+}
 
-	return Note{Header: header, Body: bodyRaw}, nil
+// ConvertHeader rewrites header of marshalled Note in format of given codec,
+// keeping the body byte-for-byte. Blank lines preceding the header are
+// dropped, as front matter has to start the file. Returns false if the header
+// is in that format already.
+func ConvertHeader(content string, codec HeaderCodec) (string, bool, error) {
+	raw, err := SplitNote(content)
+	if err != nil {
+		return "", false, err
+	}
+	if raw.Codec.Name() == codec.Name() {
+		return content, false, nil
+	}
+	note, err := UnmarshallNote(content)
+	if err != nil {
+		return "", false, err
+	}
+	header, err := codec.Marshal(note.Header)
+	if err != nil {
+		return "", false, err
+	}
+	newline := "\n"
+	if strings.HasSuffix(raw.Opening, "\r\n") {
+		newline = "\r\n"
+		header = strings.ReplaceAll(header, "\n", "\r\n")
+	}
+	closingNewline := strings.TrimPrefix(raw.Closing, strings.TrimRight(raw.Closing, "\r\n"))
+	return codec.Opening() + newline + header + codec.Closing() + closingNewline + raw.Body, true, nil
+}
+
+// UidLocation points to Uid found in a file.
+type UidLocation struct {
+	Uid  string // revive:disable-line
+	Line int
+}
+
+// LocateReferences finds Uids referenced in body of marshalled Note, together
+// with numbers of lines (counting from 1) they are in.
+func LocateReferences(content string) ([]UidLocation, error) {
+	raw, err := SplitNote(content)
+	if err != nil {
+		return []UidLocation{}, err
+	}
+
+	locations := []UidLocation{}
+	for idx, line := range strings.Split(raw.Body, "\n") {
+		for _, uid := range FindUids(line) {
+			locations = append(locations, UidLocation{Uid: uid, Line: raw.BodyLine + idx})
+		}
+	}
+	return locations, nil
 }
@@ -1,5 +1,6 @@
 package notes
 
+import "errors"
 import "testing"
 
 import "github.com/stretchr/testify/assert"
@@ -53,3 +54,138 @@ func TestLoadNote(t *testing.T) {
 		t.Run(tc.testName, testFunc)
 	}
 }
+
+func TestLocateReferences(t *testing.T) {
+	// GIVEN
+	content := "```toml\n" +
+		"title = \"NOTE_TITLE\"\n" +
+		"uid = \"20240101T000000Z\"\n" +
+		"refers_to = [\"20210101T000000Z\"]\n" +
+		"```\n" +
+		"\n" +
+		"First line refers to [[20210101T000000Z]].\n" +
+		"\n" +
+		"Third line refers to [[20220101T000000Z]] and [[20230101T000000Z]].\n"
+
+	// WHEN
+	actual, err := LocateReferences(content)
+
+	// THEN
+	assert.Nil(t, err)
+	expected := []UidLocation{
+		{Uid: "20210101T000000Z", Line: 7},
+		{Uid: "20220101T000000Z", Line: 9},
+		{Uid: "20230101T000000Z", Line: 9},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestLoadNoteWithBackticksInHeader(t *testing.T) {
+	// GIVEN
+	content := "\n```toml\r\n" +
+		"title = \"Use `go vet` often\"\r\n" +
+		"uid = \"20240101T000000Z\"\r\n" +
+		"```\r\n" +
+		"\r\n" +
+		"Body.\r\n"
+
+	// WHEN
+	actual, err := UnmarshallNote(content)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "Use `go vet` often", actual.Header.Title)
+	assert.Equal(t, "Body.", actual.Body)
+}
+
+func TestLoadMalformedNote(t *testing.T) {
+	// GIVEN
+	testCases := []struct {
+		testName      string
+		content       string
+		expectedErr   error
+		expectedLine  int
+		expectedError string
+	}{
+		{
+			"Missing header",
+			"\nJust text.\n",
+			ErrMissingHeader,
+			2,
+			"2: Note has no header",
+		},
+		{
+			"Empty file",
+			"",
+			ErrMissingHeader,
+			1,
+			"1: Note has no header",
+		},
+		{
+			"Unclosed header",
+			"```toml\ntitle = \"\"\n",
+			ErrUnclosedHeader,
+			1,
+			"1: Note header is not closed",
+		},
+		{
+			"Invalid toml",
+			"```toml\ntitle = \"\"\ntags = [\"a\"\n```\n",
+			ErrInvalidHeader,
+			3,
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			_, err := UnmarshallNote(tc.content)
+
+			// THEN
+			assert.True(t, errors.Is(err, tc.expectedErr))
+			var parseErr *ParseError
+			assert.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, tc.expectedLine, parseErr.Line)
+			if tc.expectedError != "" {
+				assert.Equal(t, tc.expectedError, err.Error())
+			}
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	// GIVEN
+	content := "```toml\n" +
+		"title = \"Dune\"\n" +
+		"timestamp = \"2024-01-01T00:00:00+00:00\"\n" +
+		"uid = \"20240101T000000Z\"\n" +
+		"tags = []\n" +
+		"referred_from = []\n" +
+		"refers_to = []\n" +
+		"authors = [\"Frank Herbert\"]\n" +
+		"read = 2024-01-01\n" +
+		"status = \"draft\"\n" +
+		"```\n" +
+		"\n" +
+		"Body.\n"
+
+	// WHEN
+	loaded, err := UnmarshallNote(content)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "draft", loaded.Header.FieldValue("status"))
+	assert.Equal(t, "2024-01-01", loaded.Header.FieldValue("read"))
+	assert.True(t, loaded.Header.HasField("authors", "Frank Herbert"))
+	assert.False(t, loaded.Header.HasField("status", "done"))
+
+	// WHEN
+	marshalled, err := loaded.ToToml()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, content, marshalled)
+}
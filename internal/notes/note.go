@@ -8,8 +8,8 @@ import "time"
 
 // Note is a single zettelkasten note.
 type Note struct {
-	Header Header
-	Body   string
+	Header Header `json:"header"`
+	Body   string `json:"body"`
 }
 
 // Equal checks equality of two Notes.
@@ -19,13 +19,18 @@ func (lhs *Note) Equal(rhs Note) bool {
 	return headerEq && bodyEq
 }
 
-// ToToml marshalls Note.
+// ToToml marshalls Note with header in ```toml``` fenced block.
 func (self *Note) ToToml() (res string, err error) {
-	header, err := self.Header.ToToml()
+	return self.Marshal(DefaultHeaderCodec())
+}
+
+// Marshal formats Note with header in format of given codec.
+func (self *Note) Marshal(codec HeaderCodec) (string, error) {
+	header, err := codec.Marshal(self.Header)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("```toml\n%s```\n\n%s\n", header, self.Body), nil
+	return fmt.Sprintf("%s\n%s%s\n\n%s\n", codec.Opening(), header, codec.Closing(), self.Body), nil
 }
 
 // Arrange enforces unified style of Notes. It modifies Note in place.
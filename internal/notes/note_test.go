@@ -67,3 +67,37 @@ func TestArrangeNote(t *testing.T) {
 	assert.Equal(t, note.Header.ReferredFrom, expectedReferredFrom)
 	assert.Equal(t, note.Header.RefersTo, expectedRefersTo)
 }
+
+func TestHeaderGetTime(t *testing.T) {
+	// GIVEN
+	header := NewHeader(time.Date(2024, 1, 1, 1, 1, 1, 0, time.FixedZone("CET", 3600)))
+
+	// WHEN
+	actual, err := header.GetTime()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.True(t, time.Date(2024, 1, 1, 0, 1, 1, 0, time.UTC).Equal(actual))
+
+	// WHEN timestamp is malformed, UID is used.
+	header.Timestamp = "yesterday"
+	actual, err = header.GetTime()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.True(t, time.Date(2024, 1, 1, 0, 1, 1, 0, time.UTC).Equal(actual))
+}
+
+func TestFindFreeTime(t *testing.T) {
+	// GIVEN
+	when := time.Date(2024, 3, 15, 12, 30, 0, 500, time.UTC)
+	taken := map[string]bool{"20240315T123000Z": true, "20240315T123001Z": true}
+	isTaken := func(uid string) bool { return taken[uid] }
+
+	// WHEN
+	actual := FindFreeTime(when, isTaken)
+
+	// THEN
+	assert.Equal(t, time.Date(2024, 3, 15, 12, 30, 2, 0, time.UTC), actual)
+	assert.Equal(t, when, FindFreeTime(when, func(string) bool { return false }))
+}
@@ -32,6 +32,55 @@ func FindReferences(repository INoteRepository) ReferenceMap {
 	return refersTo
 }
 
+// DanglingReference is a reference to Note which does not exist.
+type DanglingReference struct {
+	Source string
+	Target string
+	Line   int
+}
+
+// FindDanglingReferences returns references from Notes of repository to Uids
+// absent in existing, ordered by source Note and line. Malformed Notes are
+// skipped.
+func FindDanglingReferences(repository *FilesystemNoteRepository, existing []string) ([]DanglingReference, error) {
+	uids, err := repository.List()
+	if err != nil {
+		return []DanglingReference{}, errors.Join(err, errors.New("Cannot list note uids"))
+	}
+	slices.Sort(uids)
+
+	isExisting := make(map[string]bool)
+	for _, uid := range existing {
+		isExisting[uid] = true
+	}
+
+	dangling := []DanglingReference{}
+	for _, uid := range uids {
+		content, err := repository.GetRaw(uid)
+		if err != nil {
+			return []DanglingReference{}, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+		}
+		locations, err := LocateReferences(content)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if err != nil {
+			return []DanglingReference{}, errors.Join(err, fmt.Errorf("Cannot find references in note with UID '%s'", uid))
+		}
+		for _, location := range locations {
+			if isExisting[location.Uid] {
+				continue
+			}
+			dangling = append(
+				dangling,
+				DanglingReference{Source: uid, Target: location.Uid, Line: location.Line},
+			)
+		}
+	}
+	return dangling, nil
+}
+
 // ReverseReferences inverts given references map by swapping keys with values.
 // If values length >1, then many keys are created.
 func ReverseReferences(refersTo ReferenceMap) ReferenceMap {
@@ -53,42 +102,162 @@ func ReverseReferences(refersTo ReferenceMap) ReferenceMap {
 	return referredBy
 }
 
-// LinkNotes seeks for references in Notes and adjusts their Headers with
-// RefersTo and ReferredFrom.
-func LinkNotes(repository INoteRepository) error {
+// HeaderChange describes how Header of a Note is going to be modified.
+type HeaderChange struct {
+	Uid    string // revive:disable-line
+	Before Header
+	After  Header
+}
+
+// AddedRefersTo lists Uids which are going to be added to RefersTo.
+func (self *HeaderChange) AddedRefersTo() []string {
+	return subtract(self.After.RefersTo, self.Before.RefersTo)
+}
+
+// RemovedRefersTo lists Uids which are going to be removed from RefersTo.
+func (self *HeaderChange) RemovedRefersTo() []string {
+	return subtract(self.Before.RefersTo, self.After.RefersTo)
+}
+
+// AddedReferredFrom lists Uids which are going to be added to ReferredFrom.
+func (self *HeaderChange) AddedReferredFrom() []string {
+	return subtract(self.After.ReferredFrom, self.Before.ReferredFrom)
+}
+
+// RemovedReferredFrom lists Uids which are going to be removed from
+// ReferredFrom.
+func (self *HeaderChange) RemovedReferredFrom() []string {
+	return subtract(self.Before.ReferredFrom, self.After.ReferredFrom)
+}
+
+// AddedIndexedIn lists indices which are going to be added to IndexedIn.
+func (self *HeaderChange) AddedIndexedIn() []string {
+	return subtract(self.After.IndexedIn, self.Before.IndexedIn)
+}
+
+// RemovedIndexedIn lists indices which are going to be removed from
+// IndexedIn.
+func (self *HeaderChange) RemovedIndexedIn() []string {
+	return subtract(self.Before.IndexedIn, self.After.IndexedIn)
+}
+
+// subtract returns items of lhs which are not present in rhs.
+func subtract(lhs []string, rhs []string) []string {
+	diff := []string{}
+	for _, item := range lhs {
+		if !slices.Contains(rhs, item) {
+			diff = append(diff, item)
+		}
+	}
+	return diff
+}
+
+// PlanLinks seeks for references in Notes and returns changes of Headers that
+// LinkNotes would make, without saving anything. RefersTo and ReferredFrom
+// are reconciled with current bodies of Notes, so references which are gone
+// are cleared. IndexedIn is set from indexedIn, which maps Uids of Notes to
+// names of indices listing them. Notes whose Headers are already up to date
+// are omitted, as are malformed Notes, see FindMalformedNotes.
+func PlanLinks(repository INoteRepository, indexedIn ReferenceMap) ([]HeaderChange, error) {
 	allRefersTo := FindReferences(repository)
 	allReferredFrom := ReverseReferences(allRefersTo)
 
 	uids, err := repository.List()
 	if err != nil {
-		return errors.Join(err, errors.New("Cannot list note uids"))
+		return []HeaderChange{}, errors.Join(err, errors.New("Cannot list note uids"))
 	}
+	slices.Sort(uids)
 
+	changes := []HeaderChange{}
 	for _, uid := range uids {
-		refersTo, doesNoteReferTo := allRefersTo[uid]
-		referredFrom, isNoteReferredFrom := allReferredFrom[uid]
-
-		if !doesNoteReferTo && !isNoteReferredFrom {
+		nt, err := repository.Get(uid)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
 			continue
 		}
-
-		nt, err := repository.Get(uid)
 		if err != nil {
-			return errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+			return []HeaderChange{}, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
 		}
 
-		if doesNoteReferTo {
-			nt.Header.RefersTo = refersTo
+		// Notes absent in maps do not refer or are not referred anymore, so
+		// whatever is left in their headers is stale.
+		after := nt.Header
+		after.RefersTo = allRefersTo[uid]
+		if after.RefersTo == nil {
+			after.RefersTo = []string{}
+		}
+		after.ReferredFrom = allReferredFrom[uid]
+		if after.ReferredFrom == nil {
+			after.ReferredFrom = []string{}
+		}
+		after.IndexedIn = nil
+		if len(indexedIn[uid]) > 0 {
+			after.IndexedIn = slices.Clone(indexedIn[uid])
+			slices.Sort(after.IndexedIn)
+			after.IndexedIn = slices.Compact(after.IndexedIn)
 		}
-		if isNoteReferredFrom {
-			nt.Header.ReferredFrom = referredFrom
+		if after.Equal(nt.Header) {
+			continue
+		}
+		changes = append(changes, HeaderChange{Uid: uid, Before: nt.Header, After: after})
+	}
+
+	return changes, nil
+}
+
+// LinkNotes seeks for references in Notes and adjusts their Headers with
+// RefersTo, ReferredFrom and IndexedIn. Only Notes with changed Headers are
+// saved, so others keep their modification times. Returns changes that were
+// made.
+func LinkNotes(repository INoteRepository, indexedIn ReferenceMap) ([]HeaderChange, error) {
+	changes, err := PlanLinks(repository, indexedIn)
+	if err != nil {
+		return []HeaderChange{}, err
+	}
+
+	for _, change := range changes {
+		nt, err := repository.Get(change.Uid)
+		if err != nil {
+			return []HeaderChange{}, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", change.Uid))
 		}
 
+		nt.Header = change.After
 		_, err = repository.Put(nt)
 		if err != nil {
-			return errors.Join(err, fmt.Errorf("Cannot save note with UID '%s'", uid))
+			return []HeaderChange{}, errors.Join(err, fmt.Errorf("Cannot save note with UID '%s'", change.Uid))
 		}
 	}
 
-	return nil
+	return changes, nil
+}
+
+// MalformedNote is a Note which cannot be loaded.
+type MalformedNote struct {
+	Uid string // revive:disable-line
+	Err *ParseError
+}
+
+// FindMalformedNotes returns Notes of repository which cannot be loaded,
+// ordered by Uid. Other problems, e.g. unreadable files, are returned as
+// error.
+func FindMalformedNotes(repository INoteRepository) ([]MalformedNote, error) {
+	uids, err := repository.List()
+	if err != nil {
+		return []MalformedNote{}, errors.Join(err, errors.New("Cannot list note uids"))
+	}
+	slices.Sort(uids)
+
+	malformed := []MalformedNote{}
+	for _, uid := range uids {
+		_, err := repository.Get(uid)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			malformed = append(malformed, MalformedNote{Uid: uid, Err: parseErr})
+			continue
+		}
+		if err != nil {
+			return []MalformedNote{}, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+		}
+	}
+	return malformed, nil
 }
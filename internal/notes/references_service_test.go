@@ -98,7 +98,7 @@ func TestLinkNotes(t *testing.T) {
 	repository.Put(note2)
 
 	// WHEN
-	err := LinkNotes(repository)
+	_, err := LinkNotes(repository, nil)
 	note1, _ = repository.Get(note1uid)
 	note2, _ = repository.Get(note2uid)
 
@@ -108,3 +108,145 @@ func TestLinkNotes(t *testing.T) {
 	assert.Equal(t, []string{note2uid}, note1.Header.ReferredFrom)
 	assert.Equal(t, []string{note1uid, uid21}, note2.Header.RefersTo)
 }
+
+func TestPlanLinks(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note1uid := note1.Header.Uid
+	uid11 := "20240101T010101Z"
+	note1.Body = fmt.Sprintf("Refers to [[%s]]", uid11)
+	note1.Header.RefersTo = []string{"20230101T010101Z"}
+
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2uid := note2.Header.Uid
+	note2.Body = fmt.Sprintf("Refers to [[%s]]", note1uid)
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+
+	// WHEN
+	changes, err := PlanLinks(repository, nil)
+	unchanged, _ := repository.Get(note1uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, note1uid, changes[0].Uid)
+	assert.Equal(t, []string{uid11}, changes[0].AddedRefersTo())
+	assert.Equal(t, []string{"20230101T010101Z"}, changes[0].RemovedRefersTo())
+	assert.Equal(t, []string{note2uid}, changes[0].AddedReferredFrom())
+	assert.Equal(t, []string{}, changes[0].RemovedReferredFrom())
+	assert.Equal(t, note2uid, changes[1].Uid)
+	assert.Equal(t, []string{note1uid}, changes[1].AddedRefersTo())
+
+	// Repository is left intact.
+	assert.Equal(t, []string{"20230101T010101Z"}, unchanged.Header.RefersTo)
+}
+
+// TestLinkNotesSkipsUpToDateNotes verifies if notes are not saved again when
+// their headers already reflect references.
+func TestLinkNotesSkipsUpToDateNotes(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Body = fmt.Sprintf("Refers to [[%s]]", note1.Header.Uid)
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+
+	changes, err := LinkNotes(repository, nil)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+
+	// WHEN
+	changes, err = LinkNotes(repository, nil)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+}
+
+// TestLinkNotesClearsStaleReferences verifies if references are removed from
+// headers after links are removed from bodies.
+func TestLinkNotesClearsStaleReferences(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Body = fmt.Sprintf("Refers to [[%s]]", note1.Header.Uid)
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+
+	_, err := LinkNotes(repository, nil)
+	assert.Nil(t, err)
+
+	// WHEN
+	note2, _ = repository.Get(note2.Header.Uid)
+	note2.Body = "Refers to nothing."
+	repository.Put(note2)
+	changes, err := LinkNotes(repository, nil)
+	note1, _ = repository.Get(note1.Header.Uid)
+	note2, _ = repository.Get(note2.Header.Uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, []string{}, note1.Header.ReferredFrom)
+	assert.Equal(t, []string{}, note2.Header.RefersTo)
+}
+
+// TestLinkNotesSetsIndexedIn verifies if notes listed in indices get names of
+// these indices, and lose them once they are not listed anymore.
+func TestLinkNotesSetsIndexedIn(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+	indexedIn := ReferenceMap{note1.Header.Uid: {"ideas", "books", "ideas"}}
+
+	// WHEN
+	changes, err := LinkNotes(repository, indexedIn)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, []string{"books", "ideas"}, changes[0].AddedIndexedIn())
+	linked, _ := repository.Get(note1.Header.Uid)
+	assert.Equal(t, []string{"books", "ideas"}, linked.Header.IndexedIn)
+
+	// WHEN
+	changes, err = LinkNotes(repository, ReferenceMap{})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, []string{"books", "ideas"}, changes[0].RemovedIndexedIn())
+	unlinked, _ := repository.Get(note1.Header.Uid)
+	assert.Nil(t, unlinked.Header.IndexedIn)
+	marshalled, _ := unlinked.ToToml()
+	assert.NotContains(t, marshalled, "indexed_in")
+}
+
+func TestFindDanglingReferences(t *testing.T) {
+	// GIVEN
+	repository := NewFilesystemNoteRepository(t.TempDir())
+	repository.PutRaw(
+		"20240101T000000Z",
+		"```toml\nuid = \"20240101T000000Z\"\n```\n\nSee [[20240102T000000Z]].\nAlso [[20300101T000000Z]].\n",
+	)
+	repository.PutRaw("20240102T000000Z", "No header, but [[20300101T000000Z]].\n")
+	existing := []string{"20240101T000000Z", "20240102T000000Z"}
+
+	// WHEN
+	actual, err := FindDanglingReferences(repository, existing)
+
+	// THEN
+	assert.Nil(t, err)
+	expected := []DanglingReference{{Source: "20240101T000000Z", Target: "20300101T000000Z", Line: 6}}
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,149 @@
+package notes
+
+import "errors"
+import "fmt"
+import "strings"
+import "unicode/utf8"
+
+// snippetLength limits length of SearchResult.Snippet, in runes.
+const snippetLength = 80
+
+// SearchQuery describes what Notes should contain to be found.
+type SearchQuery struct {
+	// Terms are words or phrases; all of them must be present in a Note.
+	Terms         []string
+	CaseSensitive bool
+}
+
+// SearchResult is a single Note that matched SearchQuery.
+type SearchResult struct {
+	Uid     string `json:"uid"` // revive:disable-line
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+}
+
+// ParseSearchQuery splits text into search terms. Fragments wrapped in double
+// quotes are kept together as phrases, e.g. `go "error handling"` gives two
+// terms: `go` and `error handling`.
+func ParseSearchQuery(text string, caseSensitive bool) (SearchQuery, error) {
+	terms := []string{}
+	for idx, fragment := range strings.Split(text, `"`) {
+		isPhrase := idx%2 == 1
+		if isPhrase {
+			phrase := strings.Join(strings.Fields(fragment), " ")
+			if phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(fragment)...)
+	}
+	if strings.Count(text, `"`)%2 == 1 {
+		return SearchQuery{}, errors.New("Search query contains unclosed quote")
+	}
+	if len(terms) == 0 {
+		return SearchQuery{}, errors.New("Search query is empty")
+	}
+	return SearchQuery{Terms: terms, CaseSensitive: caseSensitive}, nil
+}
+
+// Matches checks if every term of SearchQuery can be found in title, tags or
+// body of the Note. Terms match at beginning of words, e.g. `err` finds
+// `errors`, but `rror` does not.
+func (self *SearchQuery) Matches(note Note) bool {
+	haystack := strings.Join(
+		[]string{note.Header.Title, strings.Join(note.Header.Tags, " "), note.Body},
+		" ",
+	)
+	haystack = self.normalize(haystack)
+	for _, term := range self.Terms {
+		if !containsAtWordStart(haystack, self.normalize(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Snippet returns first line of Note's body containing any of the terms,
+// shortened to fit in a single line of output. If terms were found only in
+// title or tags, empty string is returned.
+func (self *SearchQuery) Snippet(note Note) string {
+	for _, line := range strings.Split(note.Body, "\n") {
+		normalized := self.normalize(line)
+		for _, term := range self.Terms {
+			if containsAtWordStart(normalized, self.normalize(term)) {
+				return shorten(strings.Join(strings.Fields(line), " "), snippetLength)
+			}
+		}
+	}
+	return ""
+}
+
+// normalize brings text to the form in which terms are compared, i.e. with
+// collapsed whitespace, so phrases can span line breaks, and lowercased unless
+// search is case sensitive.
+func (self *SearchQuery) normalize(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if !self.CaseSensitive {
+		text = strings.ToLower(text)
+	}
+	return text
+}
+
+// containsAtWordStart checks if term occurs in text at beginning of a word,
+// i.e. not right after a letter or digit.
+func containsAtWordStart(text string, term string) bool {
+	first, _ := utf8.DecodeRuneInString(term)
+	for offset := 0; offset <= len(text); {
+		idx := strings.Index(text[offset:], term)
+		if idx < 0 {
+			return false
+		}
+		idx += offset
+		previous, _ := utf8.DecodeLastRuneInString(text[:idx])
+		if idx == 0 || !isWordRune(previous) || !isWordRune(first) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[idx:])
+		offset = idx + size
+	}
+	return false
+}
+
+func shorten(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-3]) + "..."
+}
+
+// SearchIndexedNotes returns Notes from repository matching the query,
+// ordered by their Uids. Only Notes that InvertedIndex considers candidates
+// are read, so index must be up to date with the repository. Malformed Notes
+// are skipped.
+func SearchIndexedNotes(
+	repository INoteRepository,
+	index *InvertedIndex,
+	query SearchQuery,
+) ([]SearchResult, error) {
+	results := []SearchResult{}
+	for _, uid := range index.Candidates(query) {
+		nt, err := repository.Get(uid)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if err != nil {
+			return []SearchResult{}, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+		}
+		if !query.Matches(nt) {
+			continue
+		}
+		results = append(
+			results,
+			SearchResult{Uid: uid, Title: nt.Header.Title, Snippet: query.Snippet(nt)},
+		)
+	}
+	return results, nil
+}
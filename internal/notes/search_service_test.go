@@ -0,0 +1,134 @@
+package notes
+
+import "os"
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func TestParseSearchQuery(t *testing.T) {
+	// WHEN
+	query, err := ParseSearchQuery(`go  "error   handling" tests`, false)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"go", "error handling", "tests"}, query.Terms)
+}
+
+func TestParseSearchQueryWithUnclosedQuote(t *testing.T) {
+	// WHEN
+	_, err := ParseSearchQuery(`"error handling`, false)
+
+	// THEN
+	assert.NotNil(t, err)
+}
+
+func TestSearchIndexedNotes(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note1.Header.Title = "Errors in Go"
+	note1.Header.Tags = []string{"topic:go"}
+	note1.Body = "Intro.\nGo favours explicit error\nhandling over exceptions."
+
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Header.Title = "Exceptions in Python"
+	note2.Body = "Python handles error with exceptions."
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+	index := NewInvertedIndex()
+	_, err := index.Update(repository, modtimesOf(map[string]time.Time{}))
+	assert.Nil(t, err)
+
+	testCases := []struct {
+		testName      string
+		query         string
+		caseSensitive bool
+		expected      []SearchResult
+	}{
+		{
+			"Phrase across lines",
+			`"error handling"`,
+			false,
+			[]SearchResult{{Uid: note1.Header.Uid, Title: note1.Header.Title, Snippet: ""}},
+		},
+		{
+			"Words from title and tags",
+			"errors topic:go",
+			false,
+			[]SearchResult{{Uid: note1.Header.Uid, Title: note1.Header.Title, Snippet: ""}},
+		},
+		{
+			"Words in many notes",
+			"exceptions",
+			false,
+			[]SearchResult{
+				{Uid: note1.Header.Uid, Title: note1.Header.Title, Snippet: "handling over exceptions."},
+				{Uid: note2.Header.Uid, Title: note2.Header.Title, Snippet: "Python handles error with exceptions."},
+			},
+		},
+		{
+			"Beginning of word",
+			"handl",
+			false,
+			[]SearchResult{
+				{Uid: note1.Header.Uid, Title: note1.Header.Title, Snippet: "handling over exceptions."},
+				{Uid: note2.Header.Uid, Title: note2.Header.Title, Snippet: "Python handles error with exceptions."},
+			},
+		},
+		{
+			"Middle of word",
+			"rror",
+			false,
+			[]SearchResult{},
+		},
+		{
+			"Case sensitive",
+			"python",
+			true,
+			[]SearchResult{},
+		},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			query, err := ParseSearchQuery(tc.query, tc.caseSensitive)
+			assert.Nil(t, err)
+
+			// WHEN
+			actual, err := SearchIndexedNotes(repository, &index, query)
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		}
+
+		t.Run(tc.testName, testFunc)
+	}
+}
+
+func TestSearchIndexedNotesSkipsMalformedNotes(t *testing.T) {
+	// GIVEN
+	tmpdir := t.TempDir()
+	repository := NewFilesystemNoteRepository(tmpdir)
+	valid := NewNote(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	valid.Body = "Error handling."
+	repository.Put(valid)
+	malformed := NewNote(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	malformed.Body = "Error handling, soon without header."
+	repository.Put(malformed)
+	index := NewInvertedIndex()
+	_, err := index.Update(repository, modtimesOf(map[string]time.Time{}))
+	assert.Nil(t, err)
+	os.WriteFile(repository.GetNotePath(malformed.Header.Uid), []byte("Error handling."), 0644)
+	query, err := ParseSearchQuery("error", false)
+	assert.Nil(t, err)
+
+	// WHEN
+	actual, err := SearchIndexedNotes(repository, &index, query)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []SearchResult{{Uid: valid.Header.Uid, Snippet: "Error handling."}}, actual)
+}
@@ -0,0 +1,206 @@
+package notes
+
+import "errors"
+import "fmt"
+import "strings"
+
+// TagQuery is a parsed filter expression over Header.Tags, e.g.
+// `topic:go AND NOT lang:pl` or `(topic:* OR idea) AND lang:en`.
+//
+// Operators are NOT, AND and OR (in order of precedence) and must be written
+// uppercase; adjacent patterns without operator are joined with AND. Patterns
+// are namespace aware, i.e. `namespace:value` parts are matched separately and
+// may contain `*` wildcard: `topic:*` matches every tag in `topic` namespace,
+// `*:go` matches `go` in any namespace, and pattern without colon matches only
+// tags without namespace. Matching is case insensitive.
+type TagQuery struct {
+	root tagExpression
+}
+
+// Matches checks if given tags satisfy the query.
+func (self *TagQuery) Matches(tags []string) bool {
+	return self.root.matches(tags)
+}
+
+// ParseTagQuery compiles filter expression to TagQuery.
+func ParseTagQuery(text string) (TagQuery, error) {
+	parser := tagQueryParser{tokens: tokenizeTagQuery(text)}
+	if len(parser.tokens) == 0 {
+		return TagQuery{}, errors.New("Tag query is empty")
+	}
+	root, err := parser.parseOr()
+	if err != nil {
+		return TagQuery{}, errors.Join(err, fmt.Errorf("Invalid tag query '%s'", text))
+	}
+	if !parser.done() {
+		return TagQuery{}, fmt.Errorf("Invalid tag query '%s': unexpected '%s'", text, parser.peek())
+	}
+	return TagQuery{root: root}, nil
+}
+
+type tagExpression interface {
+	matches(tags []string) bool
+}
+
+type tagAnd struct{ lhs, rhs tagExpression }
+
+func (self tagAnd) matches(tags []string) bool {
+	return self.lhs.matches(tags) && self.rhs.matches(tags)
+}
+
+type tagOr struct{ lhs, rhs tagExpression }
+
+func (self tagOr) matches(tags []string) bool {
+	return self.lhs.matches(tags) || self.rhs.matches(tags)
+}
+
+type tagNot struct{ operand tagExpression }
+
+func (self tagNot) matches(tags []string) bool {
+	return !self.operand.matches(tags)
+}
+
+type tagPattern struct {
+	namespace    string
+	value        string
+	hasNamespace bool
+}
+
+func newTagPattern(text string) tagPattern {
+	namespace, value, hasNamespace := strings.Cut(strings.ToLower(text), ":")
+	if !hasNamespace {
+		return tagPattern{value: namespace}
+	}
+	return tagPattern{namespace: namespace, value: value, hasNamespace: true}
+}
+
+func (self tagPattern) matches(tags []string) bool {
+	for _, tag := range tags {
+		namespace, value, hasNamespace := strings.Cut(strings.ToLower(tag), ":")
+		if !hasNamespace {
+			value, namespace = namespace, ""
+		}
+		if hasNamespace != self.hasNamespace {
+			continue
+		}
+		if wildcardMatch(self.namespace, namespace) && wildcardMatch(self.value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch checks if text matches pattern, in which `*` stands for any,
+// possibly empty, sequence of characters.
+func wildcardMatch(pattern string, text string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == text
+	}
+	if !strings.HasPrefix(text, parts[0]) {
+		return false
+	}
+	text = text[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(text, part)
+		if idx < 0 {
+			return false
+		}
+		text = text[idx+len(part):]
+	}
+	return strings.HasSuffix(text, parts[len(parts)-1])
+}
+
+func tokenizeTagQuery(text string) []string {
+	text = strings.ReplaceAll(text, "(", " ( ")
+	text = strings.ReplaceAll(text, ")", " ) ")
+	return strings.Fields(text)
+}
+
+type tagQueryParser struct {
+	tokens []string
+	pos    int
+}
+
+func (self *tagQueryParser) done() bool {
+	return self.pos >= len(self.tokens)
+}
+
+func (self *tagQueryParser) peek() string {
+	if self.done() {
+		return ""
+	}
+	return self.tokens[self.pos]
+}
+
+func (self *tagQueryParser) next() string {
+	token := self.peek()
+	self.pos++
+	return token
+}
+
+func (self *tagQueryParser) parseOr() (tagExpression, error) {
+	lhs, err := self.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for self.peek() == "OR" {
+		self.next()
+		rhs, err := self.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		lhs = tagOr{lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+func (self *tagQueryParser) parseAnd() (tagExpression, error) {
+	lhs, err := self.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for !self.done() && self.peek() != "OR" && self.peek() != ")" {
+		if self.peek() == "AND" {
+			self.next()
+		}
+		rhs, err := self.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		lhs = tagAnd{lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+func (self *tagQueryParser) parseNot() (tagExpression, error) {
+	if self.peek() == "NOT" {
+		self.next()
+		operand, err := self.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return tagNot{operand: operand}, nil
+	}
+	return self.parsePrimary()
+}
+
+func (self *tagQueryParser) parsePrimary() (tagExpression, error) {
+	token := self.next()
+	switch token {
+	case "":
+		return nil, errors.New("Unexpected end of tag query")
+	case "(":
+		expr, err := self.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if self.next() != ")" {
+			return nil, errors.New("Missing closing parenthesis")
+		}
+		return expr, nil
+	case ")", "AND", "OR":
+		return nil, fmt.Errorf("Unexpected '%s'", token)
+	}
+	return newTagPattern(token), nil
+}
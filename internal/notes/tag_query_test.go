@@ -0,0 +1,59 @@
+package notes
+
+import "testing"
+
+import "github.com/stretchr/testify/assert"
+
+func TestTagQuery(t *testing.T) {
+	// GIVEN
+	tags := []string{"lang:en", "topic:go", "whatever"}
+
+	testCases := []struct {
+		query    string
+		expected bool
+	}{
+		{"topic:go", true},
+		{"topic:rust", false},
+		{"TOPIC:Go", true},
+		{"topic:go AND NOT lang:pl", true},
+		{"topic:go AND NOT lang:en", false},
+		{"topic:go NOT lang:en", false},
+		{"topic:rust OR lang:en", true},
+		{"topic:*", true},
+		{"topic:g*", true},
+		{"*:go", true},
+		{"go", false},
+		{"whatever", true},
+		{"what*", true},
+		{"lang:*", true},
+		{"NOT (topic:rust OR lang:pl) AND whatever", true},
+		{"NOT topic:*", false},
+	}
+
+	for _, tc := range testCases {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			query, err := ParseTagQuery(tc.query)
+
+			// THEN
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, query.Matches(tags))
+		}
+
+		t.Run(tc.query, testFunc)
+	}
+}
+
+func TestTagQueryInvalid(t *testing.T) {
+	for _, query := range []string{"", "topic:go AND", "(topic:go", "topic:go)", "OR lang:en"} {
+		testFunc := func(t *testing.T) {
+			// WHEN
+			_, err := ParseTagQuery(query)
+
+			// THEN
+			assert.NotNil(t, err)
+		}
+
+		t.Run(query, testFunc)
+	}
+}
@@ -0,0 +1,61 @@
+package notes
+
+import "errors"
+import "fmt"
+import "slices"
+import "strings"
+
+// ReplaceTags swaps every tag from replaced with replacement in all Notes of
+// repository. Empty replacement deletes tags. Modified Notes are arranged with
+// Header.Arrange, so tags stay lowercase, sorted and unique. Malformed Notes
+// are skipped. Notes whose tags end up the same are not saved. Returns number
+// of modified Notes.
+func ReplaceTags(repository INoteRepository, replaced []string, replacement string) (int, error) {
+	uids, err := repository.List()
+	if err != nil {
+		return 0, errors.Join(err, errors.New("Cannot list note uids"))
+	}
+
+	replaced = slices.Clone(replaced)
+	for idx := range replaced {
+		replaced[idx] = strings.ToLower(replaced[idx])
+	}
+	replacement = strings.ToLower(replacement)
+
+	modified := 0
+	for _, uid := range uids {
+		nt, err := repository.Get(uid)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if err != nil {
+			return modified, errors.Join(err, fmt.Errorf("Cannot load note with UID '%s'", uid))
+		}
+
+		isReplaced := func(tag string) bool {
+			return slices.Contains(replaced, strings.ToLower(tag))
+		}
+		if !slices.ContainsFunc(nt.Header.Tags, isReplaced) {
+			continue
+		}
+
+		before := slices.Clone(nt.Header.Tags)
+		nt.Header.Tags = slices.DeleteFunc(nt.Header.Tags, isReplaced)
+		if replacement != "" {
+			nt.Header.Tags = append(nt.Header.Tags, replacement)
+		}
+		nt.Arrange()
+		nt.Header.Tags = slices.Compact(nt.Header.Tags)
+		if slices.Equal(before, nt.Header.Tags) {
+			continue
+		}
+
+		_, err = repository.Put(nt)
+		if err != nil {
+			return modified, errors.Join(err, fmt.Errorf("Cannot save note with UID '%s'", uid))
+		}
+		modified++
+	}
+	return modified, nil
+}
@@ -0,0 +1,66 @@
+package notes
+
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+func TestReplaceTags(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note1.Header.Tags = []string{"topic:golang", "lang:en"}
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Header.Tags = []string{"Topic:Go", "topic:golang"}
+	note3 := NewNote(time.Date(1993, 3, 3, 3, 3, 3, 0, time.UTC))
+	note3.Header.Tags = []string{"lang:en"}
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+	repository.Put(note3)
+
+	// WHEN
+	modified, err := ReplaceTags(repository, []string{"topic:golang"}, "topic:go")
+	note1, _ = repository.Get(note1.Header.Uid)
+	note2, _ = repository.Get(note2.Header.Uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 2, modified)
+	assert.Equal(t, []string{"lang:en", "topic:go"}, note1.Header.Tags)
+	assert.Equal(t, []string{"topic:go"}, note2.Header.Tags)
+
+	// WHEN
+	modified, err = ReplaceTags(repository, []string{"lang:en"}, "")
+	note1, _ = repository.Get(note1.Header.Uid)
+	note3, _ = repository.Get(note3.Header.Uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 2, modified)
+	assert.Equal(t, []string{"topic:go"}, note1.Header.Tags)
+	assert.Equal(t, []string{}, note3.Header.Tags)
+}
+
+func TestReplaceTagsChangingCase(t *testing.T) {
+	// GIVEN
+	note1 := NewNote(time.Date(1991, 1, 1, 1, 1, 1, 0, time.UTC))
+	note1.Header.Tags = []string{"Topic:Go"}
+	note2 := NewNote(time.Date(1992, 2, 2, 2, 2, 2, 0, time.UTC))
+	note2.Header.Tags = []string{"topic:go"}
+
+	repository := NewInMemoryNoteRepository()
+	repository.Put(note1)
+	repository.Put(note2)
+	replaced := []string{"Topic:Go"}
+
+	// WHEN
+	modified, err := ReplaceTags(repository, replaced, "topic:go")
+	note1, _ = repository.Get(note1.Header.Uid)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 1, modified)
+	assert.Equal(t, []string{"topic:go"}, note1.Header.Tags)
+	assert.Equal(t, []string{"Topic:Go"}, replaced)
+}
@@ -0,0 +1,64 @@
+package notes
+
+import "errors"
+import "strings"
+import "text/template"
+import "time"
+
+// TemplateData holds values available to placeholders of note templates, e.g.
+// `{{.Date}}` or `{{.Uid}}`.
+type TemplateData struct {
+	Uid       string // revive:disable-line
+	Date      string
+	Time      string
+	Timestamp string
+	Workspace string
+}
+
+// NewTemplateData describes Note created at given moment in given workspace.
+// Date and Time are local, as seen by the user, while Uid and Timestamp are
+// the same as in Header.
+func NewTemplateData(when time.Time, workspace string) TemplateData {
+	header := NewHeader(when)
+	local := when.Local()
+	return TemplateData{
+		Uid:       header.Uid,
+		Date:      local.Format(time.DateOnly),
+		Time:      local.Format("15:04"),
+		Timestamp: header.Timestamp,
+		Workspace: workspace,
+	}
+}
+
+// NewNoteFromTemplate creates new Note, dated when, out of Go text/template.
+// Rendered template can be a complete note, with ```toml``` header, whose
+// title, tags, custom fields and body are taken over, or just a body. Uid,
+// Timestamp and references are always fresh.
+func NewNoteFromTemplate(when time.Time, workspace string, content string) (Note, error) {
+	tmpl, err := template.New("note").Parse(content)
+	if err != nil {
+		return Note{}, errors.Join(err, errors.New("Cannot parse note template"))
+	}
+	var rendered strings.Builder
+	err = tmpl.Execute(&rendered, NewTemplateData(when, workspace))
+	if err != nil {
+		return Note{}, errors.Join(err, errors.New("Cannot render note template"))
+	}
+
+	note := NewNote(when)
+	prefilled, err := UnmarshallNote(rendered.String())
+	if errors.Is(err, ErrMissingHeader) {
+		note.Body = strings.TrimSpace(rendered.String())
+		return note, nil
+	}
+	if err != nil {
+		return Note{}, errors.Join(err, errors.New("Cannot read header of note template"))
+	}
+	note.Header.Title = prefilled.Header.Title
+	if prefilled.Header.Tags != nil {
+		note.Header.Tags = prefilled.Header.Tags
+	}
+	note.Header.Extra = prefilled.Header.Extra
+	note.Body = prefilled.Body
+	return note, nil
+}
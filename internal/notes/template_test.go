@@ -0,0 +1,73 @@
+package notes
+
+import "testing"
+import "time"
+
+import "github.com/stretchr/testify/assert"
+
+// useLocalTimezone makes loc the local timezone for the duration of the test.
+func useLocalTimezone(t *testing.T, loc *time.Location) {
+	previous := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = previous })
+}
+
+func TestNewNoteFromTemplateWithHeader(t *testing.T) {
+	// GIVEN
+	useLocalTimezone(t, time.UTC)
+	when := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+	content := "```toml\n" +
+		"title = \"Meeting {{.Date}}\"\n" +
+		"uid = \"whatever\"\n" +
+		"tags = [\"meeting\", \"{{.Workspace}}\"]\n" +
+		"refers_to = [\"20200101T000000Z\"]\n" +
+		"```\n\n" +
+		"# Attendees\n\nSee {{.Uid}} at {{.Time}}."
+
+	// WHEN
+	actual, err := NewNoteFromTemplate(when, "work", content)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "Meeting 2024-03-15", actual.Header.Title)
+	assert.Equal(t, "20240315T123000Z", actual.Header.Uid)
+	assert.Equal(t, "2024-03-15T12:30:00+00:00", actual.Header.Timestamp)
+	assert.Equal(t, []string{"meeting", "work"}, actual.Header.Tags)
+	assert.Equal(t, []string{}, actual.Header.RefersTo)
+	assert.Equal(t, []string{}, actual.Header.ReferredFrom)
+	assert.Equal(t, "# Attendees\n\nSee 20240315T123000Z at 12:30.", actual.Body)
+}
+
+func TestNewNoteFromTemplateWithBodyOnly(t *testing.T) {
+	// GIVEN
+	when := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+
+	// WHEN
+	actual, err := NewNoteFromTemplate(when, "main", "\n## Summary\n\n## Quotes\n")
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, NewHeader(when), actual.Header)
+	assert.Equal(t, "## Summary\n\n## Quotes", actual.Body)
+}
+
+func TestNewNoteFromTemplateWithUnknownPlaceholder(t *testing.T) {
+	// WHEN
+	_, err := NewNoteFromTemplate(time.Now(), "main", "{{.Author}}")
+
+	// THEN
+	assert.NotNil(t, err)
+}
+
+func TestNewNoteFromTemplateInLocalTime(t *testing.T) {
+	// GIVEN
+	useLocalTimezone(t, time.FixedZone("UTC+10", 10*60*60))
+	when := time.Date(2024, 3, 15, 20, 30, 0, 0, time.UTC)
+
+	// WHEN
+	actual, err := NewNoteFromTemplate(when, "main", "{{.Date}} {{.Time}} {{.Uid}} {{.Timestamp}}")
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-03-16 06:30 20240315T203000Z 2024-03-15T20:30:00+00:00", actual.Body)
+}
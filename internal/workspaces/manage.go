@@ -1,5 +1,6 @@
 package workspaces
 
+import "encoding/json"
 import "errors"
 import "io/fs"
 import "os"
@@ -32,11 +33,38 @@ func (self Workspace) GetWorkspacePath() string {
 	return path.Join(self.rootPath, self.workspaceName)
 }
 
+// GetIndexPath constructs absolute path to index directory.
+func (self Workspace) GetIndexPath() string {
+	return path.Join(self.rootPath, self.workspaceName, IndexDirName)
+}
+
+// GetInvertedIndexPath constructs absolute path to file with inverted index
+// of notes, which speeds up searching.
+func (self Workspace) GetInvertedIndexPath() string {
+	return path.Join(self.GetIndexPath(), InvertedIndexFileName)
+}
+
 // GetName provides name of the Workspace.
 func (self Workspace) GetName() string {
 	return self.workspaceName
 }
 
+// MarshalJSON serialises Workspace with its name and paths, since fields of
+// Workspace are not exported.
+func (self Workspace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name      string `json:"name"`
+		Path      string `json:"path"`
+		NotesPath string `json:"notes_path"`
+		IndexPath string `json:"index_path"`
+	}{
+		Name:      self.GetName(),
+		Path:      self.GetWorkspacePath(),
+		NotesPath: self.GetNotesPath(),
+		IndexPath: self.GetIndexPath(),
+	})
+}
+
 // GetWorkspaces returns correct workspaces.
 func GetWorkspaces(rootPath string) ([]Workspace, error) {
 	listing, err := os.ReadDir(rootPath)
@@ -0,0 +1,40 @@
+package workspaces
+
+import "errors"
+import "fmt"
+
+import "github.com/radiand/zettelkasten/internal/notes"
+
+// GetNoteRepository creates repository spanning notes of all workspaces found
+// in rootPath. Underlying repositories are named after workspaces.
+func GetNoteRepository(rootPath string) (*notes.CompositeNoteRepository, error) {
+	foundWorkspaces, err := GetWorkspaces(rootPath)
+	if err != nil {
+		return nil, errors.Join(err, errors.New("Could not find any workspaces"))
+	}
+	repository := notes.NewCompositeNoteRepository()
+	for _, ws := range foundWorkspaces {
+		repository.Add(ws.GetName(), notes.NewFilesystemNoteRepository(ws.GetNotesPath()))
+	}
+	return repository, nil
+}
+
+// GetIndexedWorkspaces brings inverted indices of all workspaces found in
+// rootPath up to date and joins them, so notes and links between them can be
+// queried without reading every note. Indices are named after workspaces.
+func GetIndexedWorkspaces(rootPath string) (*notes.IndexedWorkspaces, error) {
+	foundWorkspaces, err := GetWorkspaces(rootPath)
+	if err != nil {
+		return nil, errors.Join(err, errors.New("Could not find any workspaces"))
+	}
+	indexed := notes.NewIndexedWorkspaces()
+	for _, ws := range foundWorkspaces {
+		repository := notes.NewFilesystemNoteRepository(ws.GetNotesPath())
+		index, err := notes.UpdateInvertedIndexFile(repository, ws.GetInvertedIndexPath())
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("Cannot index notes in workspace %s", ws.GetName()))
+		}
+		indexed.Add(ws.GetName(), &index)
+	}
+	return indexed, nil
+}
@@ -4,3 +4,11 @@ package workspaces
 const NotesDirName = "notes"
 // IndexDirName is a subdirectory of every workspace; here the index files are stored.
 const IndexDirName = "index"
+
+// InvertedIndexFileName is a file in index directory, where inverted index of
+// notes is cached.
+const InvertedIndexFileName = ".inverted_index.json"
+
+// TemplatesDirName is an optional subdirectory of every workspace; here the
+// templates of new notes are stored.
+const TemplatesDirName = "templates"
@@ -34,7 +34,8 @@ function! s:new(workspace=v:null)
     if !empty(a:workspace)
         let system_cmd = system_cmd ..  ' ' .. a:workspace
     endif
-    let new_note_path = trim(system(system_cmd))
+    " Empty input is piped, so the note gets no body from stdin.
+    let new_note_path = trim(system(system_cmd, ''))
 
     " Open buffer with new note.
     execute ":edit " . new_note_path
@@ -92,10 +93,76 @@ function! s:fzf_find()
     call fzf#run(fzf#wrap(opts))
 endfunction
 
+function! s:fzf_sink_from_listing(result)
+    " Open note selected from long listing of notes.
+    "
+    " Args:
+    "   result: string like 'UID<tab>workspace<tab>title<tab>timestamp<tab>tags'
+
+    let uid = split(a:result, "\t")[0]
+    execute ":edit " .. s:get_path_of_note(uid)
+endfunction
+
+function! s:fzf_notes(workspace=v:null)
+    " Spawn FZF window with notes listed by their titles, newest first.
+    "
+    " Args:
+    "   workspace: workspace to list notes from; all if not specified.
+
+    let source_cmd = 'zettelkasten get -l -sort timestamp notes'
+    if !empty(a:workspace)
+        let source_cmd = source_cmd .. ' ' .. a:workspace
+    endif
+    let opts = {
+    \   'source': source_cmd,
+    \   'sink': function('s:fzf_sink_from_listing'),
+    \   'options': [
+    \       '--tac',
+    \       '--delimiter', "\t",
+    \       '--with-nth', '3,5,2',
+    \       '--preview', 'cat $(zettelkasten get -p note {1})',
+    \   ],
+    \ }
+    call fzf#run(fzf#wrap(opts))
+endfunction
+
+function! s:fzf_sink_from_neighbours(result)
+    " Open note selected from neighbours listing.
+    "
+    " Args:
+    "   result: string like 'UID<tab>distance<tab>title'
+
+    let uid = split(a:result, "\t")[0]
+    execute ":edit " .. s:get_path_of_note(uid)
+endfunction
+
+function! s:fzf_neighbours(depth=1)
+    " Spawn FZF window with notes linked with the current one.
+    "
+    " Args:
+    "   depth: how many links away neighbours can be.
+
+    let uid = expand('%:t:r')
+    let opts = {
+    \   'source': printf('zettelkasten get -depth %d neighbours %s', a:depth, uid),
+    \   'sink': function('s:fzf_sink_from_neighbours'),
+    \   'options': [
+    \       '--delimiter', "\t",
+    \       '--with-nth', '3,1',
+    \       '--preview', 'cat $(zettelkasten get -p note {1})',
+    \   ],
+    \ }
+    call fzf#run(fzf#wrap(opts))
+endfunction
+
 command! -nargs=? -complete=custom,s:complete_workspaces ZkNew :call s:new(<f-args>)
 command! ZkGoto :call s:goto()
 command! ZkFZF :call s:fzf_find()
+command! -nargs=? -complete=custom,s:complete_workspaces ZkNotes :call s:fzf_notes(<f-args>)
+command! -nargs=? ZkNeighbours :call s:fzf_neighbours(<f-args>)
 
 nnoremap <leader>zk :ZkNew<Space>
 nnoremap <leader>zg :ZkGoto<CR>
 nnoremap <leader>zf :ZkFZF<CR>
+nnoremap <leader>zl :ZkNotes<CR>
+nnoremap <leader>zn :ZkNeighbours<CR>